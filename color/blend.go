@@ -0,0 +1,222 @@
+package color
+
+import (
+	"math"
+)
+
+// Decides how a new (source) color is combined with the existing (destination) one
+type BlendMode int
+
+const (
+	// Zero value, means that the mode is not chosen and works as BlendSrcOver
+	// Drawings and commands use it to fall back to a mode chosen elsewhere
+	BlendDefault BlendMode = iota
+
+	// Porter-Duff operators
+
+	BlendClear
+	BlendSrc
+	BlendDst
+	BlendSrcOver
+	BlendDstOver
+	BlendSrcIn
+	BlendDstIn
+	BlendSrcOut
+	BlendDstOut
+	BlendSrcAtop
+	BlendDstAtop
+	BlendXor
+
+	// Separable modes, composited with source-over
+
+	BlendMultiply
+	BlendScreen
+	BlendOverlay
+	BlendDarken
+	BlendLighten
+	BlendDifference
+	// Sums up the colors, the result is clamped
+	BlendAdditive
+)
+
+var blendModeNames = map[BlendMode]string{
+	BlendDefault:    "default",
+	BlendClear:      "clear",
+	BlendSrc:        "src",
+	BlendDst:        "dst",
+	BlendSrcOver:    "src-over",
+	BlendDstOver:    "dst-over",
+	BlendSrcIn:      "src-in",
+	BlendDstIn:      "dst-in",
+	BlendSrcOut:     "src-out",
+	BlendDstOut:     "dst-out",
+	BlendSrcAtop:    "src-atop",
+	BlendDstAtop:    "dst-atop",
+	BlendXor:        "xor",
+	BlendMultiply:   "multiply",
+	BlendScreen:     "screen",
+	BlendOverlay:    "overlay",
+	BlendDarken:     "darken",
+	BlendLighten:    "lighten",
+	BlendDifference: "difference",
+	BlendAdditive:   "additive",
+}
+
+func (m BlendMode) String() string {
+	if name, ok := blendModeNames[m]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Color channels scaled to the range from 0 to 1, still alpha-premultiplied
+type colorF struct {
+	r, g, b, a float64
+}
+
+func (c Color) toColorF() colorF {
+	return colorF{
+		r: float64(c.R) / math.MaxUint16,
+		g: float64(c.G) / math.MaxUint16,
+		b: float64(c.B) / math.MaxUint16,
+		a: float64(c.A) / math.MaxUint16,
+	}
+}
+
+// Channels are clamped to the alpha, so the result is always a valid premultiplied color
+func (c colorF) toColor() Color {
+	toChannel := func(val float64) uint16 {
+		return uint16(math.Round(math.Max(0, math.Min(1, val)) * math.MaxUint16))
+	}
+
+	a := toChannel(c.a)
+	return Color{
+		R: min(toChannel(c.r), a),
+		G: min(toChannel(c.g), a),
+		B: min(toChannel(c.b), a),
+		A: a,
+	}
+}
+
+// Blends src over dst, both colors are alpha-premultiplied
+// Channels are blended as they are stored, so in sRGB, see BlendLinear for blending in linear light
+func (m BlendMode) Blend(src, dst Color) Color {
+	switch m {
+	case BlendClear:
+		return Color{}
+	case BlendSrc:
+		return src
+	case BlendDst:
+		return dst
+	default:
+		return m.blendF(src.toColorF(), dst.toColorF()).toColor()
+	}
+}
+
+func (m BlendMode) blendF(s, d colorF) colorF {
+	switch m {
+	case BlendClear:
+		return colorF{}
+	case BlendSrc:
+		return s
+	case BlendDst:
+		return d
+	case BlendDstOver:
+		return porterDuff(s, d, 1-d.a, 1)
+	case BlendSrcIn:
+		return porterDuff(s, d, d.a, 0)
+	case BlendDstIn:
+		return porterDuff(s, d, 0, s.a)
+	case BlendSrcOut:
+		return porterDuff(s, d, 1-d.a, 0)
+	case BlendDstOut:
+		return porterDuff(s, d, 0, 1-s.a)
+	case BlendSrcAtop:
+		return porterDuff(s, d, d.a, 1-s.a)
+	case BlendDstAtop:
+		return porterDuff(s, d, 1-d.a, s.a)
+	case BlendXor:
+		return porterDuff(s, d, 1-d.a, 1-s.a)
+	case BlendMultiply:
+		return separable(s, d, func(cs, cd float64) float64 {
+			return cs * cd
+		})
+	case BlendScreen:
+		return separable(s, d, screen)
+	case BlendOverlay:
+		return separable(s, d, func(cs, cd float64) float64 {
+			if cd <= 0.5 {
+				return 2 * cs * cd
+			}
+			return screen(cs, 2*cd-1)
+		})
+	case BlendDarken:
+		return separable(s, d, math.Min)
+	case BlendLighten:
+		return separable(s, d, math.Max)
+	case BlendDifference:
+		return separable(s, d, func(cs, cd float64) float64 {
+			return math.Abs(cs - cd)
+		})
+	case BlendAdditive:
+		return colorF{s.r + d.r, s.g + d.g, s.b + d.b, s.a + d.a}
+	default:
+		return porterDuff(s, d, 1, 1-s.a)
+	}
+}
+
+// Blends src over dst as if src covered only a part of the pixel
+// The fully covered result is mixed with dst, so every mode keeps dst on the uncovered part
+func (m BlendMode) BlendCoverage(src, dst Color, coverage float32) Color {
+	if coverage >= 1 {
+		return m.Blend(src, dst)
+	}
+	if coverage <= 0 {
+		return dst
+	}
+
+	return dst.toColorF().mix(m.Blend(src, dst).toColorF(), float64(coverage)).toColor()
+}
+
+// Goes from c to other by the part from 0 to 1
+func (c colorF) mix(other colorF, part float64) colorF {
+	return colorF{
+		r: c.r + (other.r-c.r)*part,
+		g: c.g + (other.g-c.g)*part,
+		b: c.b + (other.b-c.b)*part,
+		a: c.a + (other.a-c.a)*part,
+	}
+}
+
+// Result is src * srcFactor + dst * dstFactor for every channel
+func porterDuff(s, d colorF, srcFactor, dstFactor float64) colorF {
+	return colorF{
+		r: s.r*srcFactor + d.r*dstFactor,
+		g: s.g*srcFactor + d.g*dstFactor,
+		b: s.b*srcFactor + d.b*dstFactor,
+		a: s.a*srcFactor + d.a*dstFactor,
+	}
+}
+
+// Mixes not premultiplied channels with mix where both colors are present
+// and composites the rest with source-over
+func separable(s, d colorF, mix func(cs, cd float64) float64) colorF {
+	blendChannel := func(cs, cd float64) float64 {
+		var mixed float64
+		if s.a > 0 && d.a > 0 {
+			mixed = s.a * d.a * mix(cs/s.a, cd/d.a)
+		}
+		return cs*(1-d.a) + cd*(1-s.a) + mixed
+	}
+
+	return colorF{
+		r: blendChannel(s.r, d.r),
+		g: blendChannel(s.g, d.g),
+		b: blendChannel(s.b, d.b),
+		a: s.a + d.a - s.a*d.a,
+	}
+}
+
+func screen(cs, cd float64) float64 {
+	return cs + cd - cs*cd
+}
@@ -0,0 +1,90 @@
+package color_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+)
+
+const full = math.MaxUint16
+const half = full / 2
+
+func TestBlendModes(t *testing.T) {
+	red := color.Color{R: full, A: full}
+	blue := color.Color{B: full, A: full}
+	halfRed := color.Color{R: half, A: half}
+	gray := color.Color{R: half, G: half, B: half, A: full}
+
+	cases := []struct {
+		mode     color.BlendMode
+		src, dst color.Color
+		expected color.Color
+	}{
+		{color.BlendDefault, halfRed, blue, color.Color{R: half, B: half + 1, A: full}},
+		{color.BlendSrcOver, halfRed, blue, color.Color{R: half, B: half + 1, A: full}},
+		{color.BlendDstOver, halfRed, blue, blue},
+		{color.BlendSrc, halfRed, blue, halfRed},
+		{color.BlendDst, halfRed, blue, blue},
+		{color.BlendClear, red, blue, color.Color{}},
+		{color.BlendSrcIn, red, halfRed, color.Color{R: half, A: half}},
+		{color.BlendDstOut, halfRed, blue, color.Color{B: half + 1, A: half + 1}},
+		{color.BlendXor, red, blue, color.Color{}},
+		{color.BlendMultiply, gray, color.Color{R: full, G: full, A: full}, color.Color{R: half, G: half, A: full}},
+		{color.BlendScreen, gray, color.Color{R: full, A: full}, color.Color{R: full, G: half, B: half, A: full}},
+		{color.BlendDarken, gray, red, color.Color{R: half, A: full}},
+		{color.BlendLighten, gray, red, color.Color{R: full, G: half, B: half, A: full}},
+		{color.BlendDifference, red, red, color.Color{A: full}},
+		{color.BlendAdditive, gray, gray, color.Color{R: full - 1, G: full - 1, B: full - 1, A: full}},
+		{color.BlendAdditive, red, red, red},
+	}
+
+	for _, c := range cases {
+		got := c.mode.Blend(c.src, c.dst)
+		if got != c.expected {
+			t.Fatalf("Unexpected result of %v blending %v over %v; \nExpected: %v; \nGot: %v", c.mode, c.src, c.dst, c.expected, got)
+		}
+	}
+}
+
+func TestBlendOverlay(t *testing.T) {
+	gray := color.Color{R: half, G: half, B: half, A: full}
+	dark := color.Color{R: full / 4, A: full}
+	light := color.Color{R: full / 4 * 3, A: full}
+
+	// Dark destination multiplies, light destination screens
+	got := color.BlendOverlay.Blend(gray, dark)
+	if got.R > dark.R+2 || got.R < dark.R-2 {
+		t.Fatalf("Overlay of gray over a dark color should keep it; \nExpected: %v; \nGot: %v", dark, got)
+	}
+	got = color.BlendOverlay.Blend(gray, light)
+	if got.R > light.R+2 || got.R < light.R-2 {
+		t.Fatalf("Overlay of gray over a light color should keep it; \nExpected: %v; \nGot: %v", light, got)
+	}
+}
+
+func TestBlendCoverage(t *testing.T) {
+	red := color.Color{R: full, A: full}
+	blue := color.Color{B: full, A: full}
+
+	cases := []struct {
+		mode     color.BlendMode
+		coverage float32
+		expected color.Color
+	}{
+		{color.BlendSrcOver, 1, red},
+		{color.BlendSrcOver, 0, blue},
+		{color.BlendSrcOver, 0.5, color.Color{R: half + 1, B: half + 1, A: full}},
+		// Uncovered part of the pixel keeps the destination for every mode
+		{color.BlendSrc, 0.5, color.Color{R: half + 1, B: half + 1, A: full}},
+		{color.BlendClear, 0.25, color.Color{B: 49151, A: 49151}},
+		{color.BlendClear, 1, color.Color{}},
+	}
+
+	for _, c := range cases {
+		got := c.mode.BlendCoverage(red, blue, c.coverage)
+		if got != c.expected {
+			t.Fatalf("Unexpected result of %v blending %v over %v with coverage %v; \nExpected: %v; \nGot: %v", c.mode, red, blue, c.coverage, c.expected, got)
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package color
 
 import (
-	"fmt"
 	std_color "image/color"
 	"math"
 )
 
-// All values are alpha-premultiplied
+// All values are alpha-premultiplied, same as in color.RGBA64 of the standard library:
+// every channel is already multiplied by the alpha, so a channel is never greater than the alpha
+// and half transparent white is {R: 0x7fff, G: 0x7fff, B: 0x7fff, A: 0x7fff}
+// Straight (not premultiplied) values, such as in color.NRGBA64, are converted with ColorFromNRGBA64 and ToNRGBA64
 type Color struct {
 	R, G, B, A uint16
 }
@@ -20,47 +22,28 @@ func (c Color) RGBA() (r, g, b, a uint32) {
 	return r, g, b, a
 }
 
-var i int
-
+// Composites c1 over c2, both colors are alpha-premultiplied
+// Same as BlendSrcOver.Blend(c1, c2)
 func (c1 Color) BlendWith(c2 Color) Color {
-	if c1.A == math.MaxUint16 || c2.A == 0 {
-		return c1
-	}
-	if c1.A == 0 {
-		return c2
-	}
-
-	var resR, resG, resB, resA uint16
-	// R G B A
+	return BlendSrcOver.Blend(c1, c2)
+}
 
-	if math.MaxUint16-c1.R > c2.R*c2.A {
-		resR = c1.R + c2.R*c2.A
-	} else {
-		resR = math.MaxUint16
+// Scales all channels of the color, factor should be in range from 0 to 1
+// Used to apply partial coverage to an alpha-premultiplied color
+func (c Color) Scale(factor float32) Color {
+	if factor >= 1 {
+		return c
 	}
-
-	if math.MaxUint16-c1.G > c2.G*c2.A {
-		resG = c1.G + c2.G*c2.A
-	} else {
-		resG = math.MaxUint16
+	if factor <= 0 {
+		return Color{}
 	}
 
-	if math.MaxUint16-c1.B > c2.B*c2.A {
-		resB = c1.B + c2.B*c2.A
-	} else {
-		resB = math.MaxUint16
+	return Color{
+		R: uint16(float32(c.R) * factor),
+		G: uint16(float32(c.G) * factor),
+		B: uint16(float32(c.B) * factor),
+		A: uint16(float32(c.A) * factor),
 	}
-
-	resA = max(c1.A, c2.A)
-
-	res := Color{resR, resG, resB, uint16(resA)}
-
-	i++
-	if i%300 == 0 {
-		fmt.Println(c1, c2, res)
-	}
-
-	return res
 }
 
 func ColorFromStdColor(c std_color.Color) Color {
@@ -74,6 +57,40 @@ func ColorFromStdColor(c std_color.Color) Color {
 	return col
 }
 
+// Premultiplies the straight channels by the alpha, the channels are rounded
+func ColorFromNRGBA64(c std_color.NRGBA64) Color {
+	premultiply := func(val uint16) uint16 {
+		return uint16((uint32(val)*uint32(c.A) + math.MaxUint16/2) / math.MaxUint16)
+	}
+
+	return Color{
+		R: premultiply(c.R),
+		G: premultiply(c.G),
+		B: premultiply(c.B),
+		A: c.A,
+	}
+}
+
+// Divides the channels by the alpha, fully transparent colors give transparent black
+// Converting the result back with ColorFromNRGBA64 gives the same color
+func (c Color) ToNRGBA64() std_color.NRGBA64 {
+	if c.A == 0 {
+		return std_color.NRGBA64{}
+	}
+
+	unpremultiply := func(val uint16) uint16 {
+		res := (uint32(val)*math.MaxUint16 + uint32(c.A)/2) / uint32(c.A)
+		return uint16(min(res, math.MaxUint16))
+	}
+
+	return std_color.NRGBA64{
+		R: unpremultiply(c.R),
+		G: unpremultiply(c.G),
+		B: unpremultiply(c.B),
+		A: c.A,
+	}
+}
+
 type InvalidGradientMark struct{}
 
 func (invalidGradient InvalidGradientMark) Error() string {
@@ -82,13 +99,68 @@ func (invalidGradient InvalidGradientMark) Error() string {
 
 // Holds an always sorted slice of gradient marks
 // Positions vary from 0 to 1
+// Spread decides the colors for progress outside of this range
+// Space is the color space colors are interpolated in, Hue is used only by spaces with a hue
 type Gradient struct {
-	Marks []GradientMark
+	Marks  []GradientMark
+	Spread SpreadMode
+	Space  ColorSpace
+	Hue    HueInterpolation
+}
+
+// Decides how a gradient continues before position 0 and after position 1
+type SpreadMode int
+
+const (
+	// Colors of the first and the last marks are used
+	SpreadPad SpreadMode = iota
+	// The gradient starts over, so it tiles
+	SpreadRepeat
+	// Every other tile of the gradient is mirrored, so there are no hard edges
+	SpreadReflect
+)
+
+var spreadModeNames = map[SpreadMode]string{
+	SpreadPad:     "pad",
+	SpreadRepeat:  "repeat",
+	SpreadReflect: "reflect",
+}
+
+func (s SpreadMode) String() string {
+	if name, ok := spreadModeNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Moves the progress into the range from 0 to 1, pad mode leaves it to be clamped
+func (s SpreadMode) apply(progress float32) float32 {
+	if progress >= 0 && progress <= 1 {
+		return progress
+	}
+
+	switch s {
+	case SpreadRepeat:
+		return progress - float32(math.Floor(float64(progress)))
+	case SpreadReflect:
+		progress = float32(math.Mod(float64(progress), 2))
+		if progress < 0 {
+			progress += 2
+		}
+		if progress > 1 {
+			progress = 2 - progress
+		}
+		return progress
+	default:
+		return progress
+	}
 }
 
+// Easing shapes the transition from this mark to the next one
 type GradientMark struct {
-	Col Color
-	Pos float32
+	Col    Color
+	Pos    float32
+	Easing Easing
 }
 
 // Flat gradient of the color passed
@@ -152,12 +224,31 @@ func (g *Gradient) SetMark(mark GradientMark) error {
 	return nil
 }
 
+// Positions outside of the range from start to end follow the spread mode
 // Assumes the gradient has at least 2 marks
 func (g *Gradient) GetMark(start, end, pos int) GradientMark {
-	if pos <= start {
+	if start == end {
+		if pos <= start {
+			return g.Marks[0]
+		}
+		return g.Marks[len(g.Marks)-1]
+	}
+
+	progress := float32(float64(pos-start) / float64(end-start))
+
+	return g.GetMarkAt(progress)
+}
+
+// Same as GetMark, but takes the position along the gradient directly
+// Progress varies from 0 to 1, values outside of the range follow the spread mode
+// Assumes the gradient has at least 2 marks
+func (g *Gradient) GetMarkAt(progress float32) GradientMark {
+	progress = g.Spread.apply(progress)
+
+	if progress <= 0 {
 		return g.Marks[0]
 	}
-	if pos >= end {
+	if progress >= 1 {
 		return g.Marks[len(g.Marks)-1]
 	}
 
@@ -166,11 +257,9 @@ func (g *Gradient) GetMark(start, end, pos int) GradientMark {
 		return g.Marks[0]
 	}
 
-	progress := float32(math.Abs(float64(pos-start) / float64(end-start)))
-
 	for i := 1; i < len(g.Marks); i++ {
 		if g.Marks[i-1].Pos <= progress && g.Marks[i].Pos >= progress {
-			col := blendMarks(g.Marks[i-1], g.Marks[i], progress)
+			col := g.blendMarks(g.Marks[i-1], g.Marks[i], progress)
 			return GradientMark{
 				Pos: progress,
 				Col: col,
@@ -181,16 +270,14 @@ func (g *Gradient) GetMark(start, end, pos int) GradientMark {
 	return g.Marks[len(g.Marks)-1]
 }
 
-func blendMarks(left, right GradientMark, progress float32) Color {
-	leftScale := right.Pos - progress
-	rightScale := progress - left.Pos
-
-	resR := blend2Vals(left.Col.R, right.Col.R, leftScale, rightScale)
-	resG := blend2Vals(left.Col.G, right.Col.G, leftScale, rightScale)
-	resB := blend2Vals(left.Col.B, right.Col.B, leftScale, rightScale)
-	resA := blend2Vals(left.Col.A, right.Col.A, leftScale, rightScale)
+func (g *Gradient) blendMarks(left, right GradientMark, progress float32) Color {
+	if right.Pos == left.Pos {
+		return right.Col
+	}
 
-	return Color{R: resR, G: resG, B: resB, A: resA}
+	t := float64((progress - left.Pos) / (right.Pos - left.Pos))
+	t = left.Easing.apply(t)
+	return interpolateColors(left.Col, right.Col, t, g.Space, g.Hue)
 }
 
 func blend2Vals(leftVal, rightVal uint16, leftScale, rightScale float32) uint16 {
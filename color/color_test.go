@@ -1,8 +1,11 @@
 package color_test
 
 import (
+	"image"
 	std_color "image/color"
+	"math"
 	"math/rand"
+	"sync"
 	"testing"
 
 	"github.com/marattttt/generator/color"
@@ -120,3 +123,203 @@ func TestColorToGradient(t *testing.T) {
 		t.Fatalf("Invalid colors in plain color gradient. \nExpected: %v; \nGot: %v", white, gradient.Marks[0].Col)
 	}
 }
+
+func TestGradientSpread(t *testing.T) {
+	grad := color.GradientFromColor(color.ColorFromStdColor(std_color.Black))
+	grad.SetMark(color.GradientMark{
+		Col: color.ColorFromStdColor(std_color.White),
+		Pos: 1,
+	})
+
+	cases := []struct {
+		spread   color.SpreadMode
+		progress float32
+		expected float32
+	}{
+		{color.SpreadPad, 1.25, 1},
+		{color.SpreadPad, -0.25, 0},
+		{color.SpreadRepeat, 1.25, 0.25},
+		{color.SpreadRepeat, -0.25, 0.75},
+		{color.SpreadReflect, 1.25, 0.75},
+		{color.SpreadReflect, 2.25, 0.25},
+		{color.SpreadReflect, -0.25, 0.25},
+	}
+
+	for _, c := range cases {
+		grad.Spread = c.spread
+		got := grad.GetMarkAt(c.progress).Col
+		expected := grad.GetMarkAt(c.expected).Col
+		if got != expected {
+			t.Fatalf("Unexpected color at %v with spread %v; \nExpected: %v; \nGot: %v", c.progress, c.spread, expected, got)
+		}
+	}
+
+	// Ends of the range are not wrapped around
+	grad.Spread = color.SpreadRepeat
+	if grad.GetMarkAt(1).Col != grad.Marks[1].Col {
+		t.Fatalf("Repeated gradient should end with the last mark")
+	}
+}
+
+func TestLinearGradientRepeats(t *testing.T) {
+	grad := color.GradientFromColor(color.ColorFromStdColor(std_color.Black))
+	grad.SetMark(color.GradientMark{
+		Col: color.ColorFromStdColor(std_color.White),
+		Pos: 1,
+	})
+	grad.Spread = color.SpreadRepeat
+
+	linear := color.NewLinearGradient(grad, image.Point{0, 0}, image.Point{10, 0})
+	for x := 0; x < 100; x++ {
+		// The end of every tile is the last mark
+		if x%10 == 0 {
+			continue
+		}
+
+		got := linear.ColorAt(x, 5, 0)
+		expected := linear.ColorAt(x%10, 0, 0)
+		// Allows for float rounding
+		if got.R > expected.R+1 || got.R+1 < expected.R {
+			t.Fatalf("[%d;5] linear gradient should repeat every 10 pixels; \nExpected: %v; \nGot: %v", x, expected, got)
+		}
+	}
+}
+
+func TestNRGBA64Conversions(t *testing.T) {
+	for a := 0; a <= math.MaxUint16; a++ {
+		alpha := uint16(a)
+		for _, val := range []uint16{0, 1, alpha / 3, alpha / 2, alpha - 1, alpha} {
+			if val > alpha {
+				continue
+			}
+
+			col := color.Color{R: val, G: val / 2, B: alpha, A: alpha}
+			straight := col.ToNRGBA64()
+
+			// Premultiplied colors survive a round trip through straight colors exactly
+			if got := color.ColorFromNRGBA64(straight); got != col {
+				t.Fatalf("Round trip of %v through %v gave %v", col, straight, got)
+			}
+
+			// Standard library truncates instead of rounding, so it may be off by 1
+			std := std_color.NRGBA64Model.Convert(col).(std_color.NRGBA64)
+			if !isNear(straight.R, std.R, 1) || !isNear(straight.G, std.G, 1) || straight.A != std.A {
+				t.Fatalf("Straight color of %v differs from the standard library; \nExpected: %v; \nGot: %v", col, std, straight)
+			}
+		}
+	}
+
+	// Straight colors lose precision when premultiplied by a small alpha
+	for a := 0; a <= math.MaxUint8; a++ {
+		for val := 0; val <= math.MaxUint8; val++ {
+			straight := std_color.NRGBA64{R: uint16(val * 0x101), A: uint16(a * 0x101)}
+			col := color.ColorFromNRGBA64(straight)
+
+			r, _, _, alpha := straight.RGBA()
+			if !isNear(col.R, uint16(r), 1) || col.A != uint16(alpha) {
+				t.Fatalf("Premultiplied color of %v differs from the standard library; \nExpected: %v; \nGot: %v",
+					straight, std_color.RGBA64{R: uint16(r), A: uint16(alpha)}, col)
+			}
+
+			if a == 0 {
+				continue
+			}
+			tolerance := uint16(math.MaxUint16/straight.A + 1)
+			if got := col.ToNRGBA64(); !isNear(got.R, straight.R, tolerance) {
+				t.Fatalf("Round trip of %v gave %v", straight, got)
+			}
+		}
+	}
+}
+
+func TestBlendWith(t *testing.T) {
+	cols := getPremultipliedColors()
+
+	for _, src := range cols {
+		for _, dst := range cols {
+			got := src.BlendWith(dst)
+
+			srcA := float64(src.A) / math.MaxUint16
+			over := func(s, d uint16) float64 {
+				return float64(s) + float64(d)*(1-srcA)
+			}
+			expected := []float64{over(src.R, dst.R), over(src.G, dst.G), over(src.B, dst.B), over(src.A, dst.A)}
+			for i, val := range []uint16{got.R, got.G, got.B, got.A} {
+				if math.Abs(float64(val)-expected[i]) > 1 {
+					t.Fatalf("Unexpected result of %v over %v; \nExpected: %v; \nGot: %v", src, dst, expected, got)
+				}
+			}
+
+			if src.A == math.MaxUint16 && got != src {
+				t.Fatalf("Opaque %v should cover %v, got %v", src, dst, got)
+			}
+			if src == (color.Color{}) && got != dst {
+				t.Fatalf("Transparent color should keep %v, got %v", dst, got)
+			}
+		}
+	}
+}
+
+func TestBlendKeepsPremultipliedColorsValid(t *testing.T) {
+	cols := getPremultipliedColors()
+
+	for mode := color.BlendDefault; mode <= color.BlendAdditive; mode++ {
+		for _, src := range cols {
+			for _, dst := range cols {
+				for _, got := range []color.Color{mode.Blend(src, dst), mode.BlendLinear(src, dst)} {
+					if got.R > got.A || got.G > got.A || got.B > got.A {
+						t.Fatalf("%v blend of %v over %v gave channels greater than the alpha: %v", mode, src, dst, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBlendWithIsDeterministic(t *testing.T) {
+	cols := getPremultipliedColors()
+	expected := make([]color.Color, len(cols))
+	for i := range cols {
+		expected[i] = cols[i].BlendWith(cols[len(cols)-1-i])
+	}
+
+	var wg sync.WaitGroup
+	for j := 0; j < 8; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range cols {
+				if got := cols[i].BlendWith(cols[len(cols)-1-i]); got != expected[i] {
+					t.Errorf("Blending %v is not deterministic; \nExpected: %v; \nGot: %v", cols[i], expected[i], got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Every valid premultiplied color with channels in steps of 0x3333
+func getPremultipliedColors() []color.Color {
+	const step = 0x3333
+	cols := make([]color.Color, 0)
+
+	for a := 0; a <= math.MaxUint16; a += step {
+		for r := 0; r <= a; r += step {
+			for g := 0; g <= a; g += step {
+				for _, b := range []int{0, a / 2, a} {
+					cols = append(cols, color.Color{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)})
+				}
+			}
+		}
+	}
+
+	return cols
+}
+
+func isNear(val1, val2, tolerance uint16) bool {
+	if val1 > val2 {
+		return val1-val2 <= tolerance
+	}
+	return val2-val1 <= tolerance
+}
@@ -0,0 +1,45 @@
+package color
+
+import (
+	"image"
+	"math"
+)
+
+// Marks go around the center clockwise, a full turn goes from position 0 to position 1
+// StartAngle is in radians, zero angle points to the right and angles grow clockwise,
+// same as atan2 with image coordinates where y grows downwards
+// Implements Paint, the color depends only on the coordinates of a pixel
+type ConicGradient struct {
+	Gradient
+	Center     image.Point
+	StartAngle float64
+}
+
+// Gives a conic gradient starting at the given angle
+func NewConicGradient(grad Gradient, center image.Point, startAngle float64) ConicGradient {
+	return ConicGradient{
+		Gradient:   grad,
+		Center:     center,
+		StartAngle: startAngle,
+	}
+}
+
+func (g ConicGradient) ColorAt(x, y int, progress float32) Color {
+	return g.GetMarkAt(g.GetProgress(x, y)).Col
+}
+
+// Part of the full turn from the start angle to the pixel, from 0 to 1
+func (g ConicGradient) GetProgress(x, y int) float32 {
+	dx := float64(x - g.Center.X)
+	dy := float64(y - g.Center.Y)
+	if dx == 0 && dy == 0 {
+		return 0
+	}
+
+	angle := math.Mod(math.Atan2(dy, dx)-g.StartAngle, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+
+	return float32(angle / (2 * math.Pi))
+}
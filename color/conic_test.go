@@ -0,0 +1,51 @@
+package color_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+)
+
+func TestConicGradientProgress(t *testing.T) {
+	grad := color.NewConicGradient(getBlackToWhite(), image.Point{100, 100}, 0)
+
+	cases := map[image.Point]float32{
+		{150, 100}: 0,
+		{100, 150}: 0.25,
+		{50, 100}:  0.5,
+		{100, 50}:  0.75,
+		{150, 150}: 0.125,
+	}
+
+	for p, expected := range cases {
+		got := grad.GetProgress(p.X, p.Y)
+		if math.Abs(float64(got-expected)) > 0.001 {
+			t.Fatalf("[%d;%d] unexpected progress; \nExpected: %v; \nGot: %v", p.X, p.Y, expected, got)
+		}
+	}
+}
+
+func TestConicGradientStartAngle(t *testing.T) {
+	// Starts at the top, like a clock
+	grad := color.NewConicGradient(getBlackToWhite(), image.Point{100, 100}, -math.Pi/2)
+
+	cases := map[image.Point]float32{
+		{100, 50}:  0,
+		{150, 100}: 0.25,
+		{100, 150}: 0.5,
+		{50, 100}:  0.75,
+	}
+
+	for p, expected := range cases {
+		got := grad.GetProgress(p.X, p.Y)
+		if math.Abs(float64(got-expected)) > 0.001 {
+			t.Fatalf("[%d;%d] unexpected progress; \nExpected: %v; \nGot: %v", p.X, p.Y, expected, got)
+		}
+	}
+
+	if grad.ColorAt(100, 50, 1) != grad.Marks[0].Col {
+		t.Fatalf("Conic gradient should ignore the progress of a shape")
+	}
+}
@@ -0,0 +1,133 @@
+package color
+
+import (
+	"fmt"
+	"math"
+)
+
+type EasingKind int
+
+const (
+	EaseLinear EasingKind = iota
+	// Same as CSS ease-in, cubic-bezier(0.42, 0, 1, 1)
+	EaseIn
+	// Same as CSS ease-out, cubic-bezier(0, 0, 0.58, 1)
+	EaseOut
+	// Same as CSS ease-in-out, cubic-bezier(0.42, 0, 0.58, 1)
+	EaseInOut
+	// Uses the control points of the easing, same as CSS cubic-bezier()
+	EaseCubicBezier
+	// Color jumps in Steps equal steps, same as CSS steps() with jump-end
+	EaseSteps
+	// Color of the mark is kept until the next mark, which makes a hard stop
+	EaseHold
+	// Hint is the position between the marks where the colors are mixed equally,
+	// same as a CSS color hint
+	EaseHint
+)
+
+var easingKindNames = map[EasingKind]string{
+	EaseLinear:      "linear",
+	EaseIn:          "ease-in",
+	EaseOut:         "ease-out",
+	EaseInOut:       "ease-in-out",
+	EaseCubicBezier: "cubic-bezier",
+	EaseSteps:       "steps",
+	EaseHold:        "hold",
+	EaseHint:        "hint",
+}
+
+func (k EasingKind) String() string {
+	if name, ok := easingKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Shapes the transition between two marks, the zero value is linear
+// Only the fields used by the kind need to be set
+type Easing struct {
+	Kind           EasingKind
+	X1, Y1, X2, Y2 float32
+	Steps          int
+	Hint           float32
+}
+
+// Formats the easing as it is written in a gradient string, hints are written as hint(<value>)
+func (e Easing) String() string {
+	switch e.Kind {
+	case EaseCubicBezier:
+		return fmt.Sprintf("cubic-bezier(%s, %s, %s, %s)",
+			formatFloat(e.X1), formatFloat(e.Y1), formatFloat(e.X2), formatFloat(e.Y2))
+	case EaseSteps:
+		return fmt.Sprintf("steps(%d)", e.Steps)
+	case EaseHint:
+		return fmt.Sprintf("hint(%s)", formatFloat(e.Hint))
+	default:
+		return e.Kind.String()
+	}
+}
+
+// Maps the position between two marks, from 0 to 1, to the mixing ratio of their colors
+func (e Easing) apply(t float64) float64 {
+	if t <= 0 || t >= 1 {
+		return t
+	}
+
+	switch e.Kind {
+	case EaseIn:
+		return cubicBezier(0.42, 0, 1, 1, t)
+	case EaseOut:
+		return cubicBezier(0, 0, 0.58, 1, t)
+	case EaseInOut:
+		return cubicBezier(0.42, 0, 0.58, 1, t)
+	case EaseCubicBezier:
+		return cubicBezier(float64(e.X1), float64(e.Y1), float64(e.X2), float64(e.Y2), t)
+	case EaseSteps:
+		steps := float64(max(e.Steps, 1))
+		return math.Floor(t*steps) / steps
+	case EaseHold:
+		return 0
+	case EaseHint:
+		hint := float64(e.Hint)
+		if hint <= 0 {
+			return 1
+		}
+		if hint >= 1 {
+			return 0
+		}
+		return math.Pow(t, math.Log(0.5)/math.Log(hint))
+	default:
+		return t
+	}
+}
+
+// Value of a curve from [0;0] to [1;1] with two control points at the given x
+// X values of the control points are clamped to the range from 0 to 1, so x is always increasing
+func cubicBezier(x1, y1, x2, y2, x float64) float64 {
+	x1 = math.Max(0, math.Min(1, x1))
+	x2 = math.Max(0, math.Min(1, x2))
+
+	sample := func(a, b, t float64) float64 {
+		// Bernstein form with the end points at 0 and 1
+		return 3*a*t*(1-t)*(1-t) + 3*b*t*t*(1-t) + t*t*t
+	}
+
+	// Finds the curve parameter for x by bisection
+	low, high := 0.0, 1.0
+	t := x
+	for i := 0; i < 50; i++ {
+		got := sample(x1, x2, t)
+		if math.Abs(got-x) < 1e-7 {
+			break
+		}
+		if got < x {
+			low = t
+		} else {
+			high = t
+		}
+		t = (low + high) / 2
+	}
+
+	return sample(y1, y2, t)
+}
@@ -0,0 +1,72 @@
+package color_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+)
+
+// Gives the red channel of a black to white gradient with the easing, from 0 to 1
+func getEasedValue(easing color.Easing, progress float32) float64 {
+	grad := getBlackToWhite()
+	grad.Marks[0].Easing = easing
+	return float64(grad.GetMarkAt(progress).Col.R) / math.MaxUint16
+}
+
+func TestEasingHold(t *testing.T) {
+	easing := color.Easing{Kind: color.EaseHold}
+	for _, progress := range []float32{0.1, 0.5, 0.99} {
+		if got := getEasedValue(easing, progress); got != 0 {
+			t.Fatalf("Hold easing should keep the first color at %v, got %v", progress, got)
+		}
+	}
+	if got := getEasedValue(easing, 1); got != 1 {
+		t.Fatalf("Hold easing should end with the next color, got %v", got)
+	}
+}
+
+func TestEasingSteps(t *testing.T) {
+	easing := color.Easing{Kind: color.EaseSteps, Steps: 4}
+	cases := map[float32]float64{
+		0.1:  0,
+		0.3:  0.25,
+		0.6:  0.5,
+		0.95: 0.75,
+	}
+
+	for progress, expected := range cases {
+		if got := getEasedValue(easing, progress); math.Abs(got-expected) > 0.001 {
+			t.Fatalf("Unexpected step at %v; \nExpected: %v; \nGot: %v", progress, expected, got)
+		}
+	}
+}
+
+func TestEasingCurves(t *testing.T) {
+	linear := getEasedValue(color.Easing{}, 0.5)
+
+	if got := getEasedValue(color.Easing{Kind: color.EaseIn}, 0.5); got >= linear {
+		t.Fatalf("Ease-in should be behind linear in the middle, got %v", got)
+	}
+	if got := getEasedValue(color.Easing{Kind: color.EaseOut}, 0.5); got <= linear {
+		t.Fatalf("Ease-out should be ahead of linear in the middle, got %v", got)
+	}
+	if got := getEasedValue(color.Easing{Kind: color.EaseInOut}, 0.5); math.Abs(got-linear) > 0.001 {
+		t.Fatalf("Ease-in-out should match linear in the middle, got %v", got)
+	}
+
+	straight := color.Easing{Kind: color.EaseCubicBezier, X1: 0.25, Y1: 0.25, X2: 0.75, Y2: 0.75}
+	for _, progress := range []float32{0.1, 0.3, 0.7} {
+		expected := getEasedValue(color.Easing{}, progress)
+		if got := getEasedValue(straight, progress); math.Abs(got-expected) > 0.001 {
+			t.Fatalf("Cubic bezier with points on the diagonal should be linear at %v; \nExpected: %v; \nGot: %v", progress, expected, got)
+		}
+	}
+}
+
+func TestEasingHint(t *testing.T) {
+	easing := color.Easing{Kind: color.EaseHint, Hint: 0.25}
+	if got := getEasedValue(easing, 0.25); math.Abs(got-0.5) > 0.001 {
+		t.Fatalf("Colors should be mixed equally at the hint, got %v", got)
+	}
+}
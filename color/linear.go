@@ -0,0 +1,39 @@
+package color
+
+import (
+	"image"
+)
+
+// Marks go from Start (position 0) to End (position 1) and stay the same across the line between them
+// Can be shorter than the drawn shape, so the spread mode is used for the rest of it
+// Implements Paint, the color depends only on the coordinates of a pixel
+type LinearGradient struct {
+	Gradient
+	Start, End image.Point
+}
+
+// Gives a linear gradient between two points
+func NewLinearGradient(grad Gradient, start, end image.Point) LinearGradient {
+	return LinearGradient{
+		Gradient: grad,
+		Start:    start,
+		End:      end,
+	}
+}
+
+func (g LinearGradient) ColorAt(x, y int, progress float32) Color {
+	return g.GetMarkAt(g.GetProgress(x, y)).Col
+}
+
+// Position of the pixel projected onto the line from Start to End
+// Is outside of the range from 0 to 1 for pixels before Start or after End
+func (g LinearGradient) GetProgress(x, y int) float32 {
+	dir := g.End.Sub(g.Start)
+	lengthSq := dir.X*dir.X + dir.Y*dir.Y
+	if lengthSq == 0 {
+		return 0
+	}
+
+	offset := image.Point{x, y}.Sub(g.Start)
+	return float32(float64(offset.X*dir.X+offset.Y*dir.Y) / float64(lengthSq))
+}
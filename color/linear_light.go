@@ -0,0 +1,80 @@
+package color
+
+// Same as Blend, but the colors are converted to linear light before blending and back to sRGB after
+// Blending sRGB values darkens the mixed colors, e.g. half covered white over black gives #808080 instead of #bcbcbc
+func (m BlendMode) BlendLinear(src, dst Color) Color {
+	switch m {
+	case BlendClear:
+		return Color{}
+	case BlendSrc:
+		return src
+	case BlendDst:
+		return dst
+	default:
+		return m.blendF(src.toColorF().toLinear(), dst.toColorF().toLinear()).toSRGB().toColor()
+	}
+}
+
+// Same as BlendCoverage, but the result is mixed with dst in linear light
+func (m BlendMode) BlendLinearCoverage(src, dst Color, coverage float32) Color {
+	if coverage >= 1 {
+		return m.BlendLinear(src, dst)
+	}
+	if coverage <= 0 {
+		return dst
+	}
+
+	blended := m.BlendLinear(src, dst).toColorF().toLinear()
+	return dst.toColorF().toLinear().mix(blended, float64(coverage)).toSRGB().toColor()
+}
+
+// Gives a paint which gradients are interpolated in linear light
+// Only gradients in SpaceSRGB are changed, other color spaces are chosen on purpose and are kept
+// Paints other than the gradients of this package are returned as they are
+func InLinearLight(paint Paint) Paint {
+	toLinear := func(g *Gradient) {
+		if g.Space == SpaceSRGB {
+			g.Space = SpaceLinearRGB
+		}
+	}
+
+	switch grad := paint.(type) {
+	case Gradient:
+		toLinear(&grad)
+		return grad
+	case LinearGradient:
+		toLinear(&grad.Gradient)
+		return grad
+	case RadialGradient:
+		toLinear(&grad.Gradient)
+		return grad
+	case ConicGradient:
+		toLinear(&grad.Gradient)
+		return grad
+	default:
+		return paint
+	}
+}
+
+// Premultiplied channels are converted through the straight color, as the transfer function is not linear
+func (c colorF) toLinear() colorF {
+	return c.mapStraight(srgbToLinear)
+}
+
+func (c colorF) toSRGB() colorF {
+	return c.mapStraight(linearToSRGB)
+}
+
+func (c colorF) mapStraight(transfer func(val float64) float64) colorF {
+	if c.a <= 0 {
+		return colorF{}
+	}
+
+	alpha := min(c.a, 1)
+	return colorF{
+		r: transfer(clamp01(c.r/alpha)) * alpha,
+		g: transfer(clamp01(c.g/alpha)) * alpha,
+		b: transfer(clamp01(c.b/alpha)) * alpha,
+		a: alpha,
+	}
+}
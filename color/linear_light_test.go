@@ -0,0 +1,85 @@
+package color_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+)
+
+// Reference values are 8 bit sRGB, computed with the sRGB transfer function from IEC 61966-2-1
+func TestBlendLinear(t *testing.T) {
+	black := color.MustParse("#000")
+	white := color.MustParse("#fff")
+	red := color.MustParse("#f00")
+	green := color.MustParse("#0f0")
+	halfWhite := color.MustParse("#ffffff80")
+	halfRed := color.MustParse("#ff000080")
+
+	cases := []struct {
+		mode     color.BlendMode
+		src, dst color.Color
+		expected string
+	}{
+		// Linear 0.5 is #bcbcbc, instead of #808080 given by sRGB blending
+		{color.BlendSrcOver, halfWhite, black, "#bcbcbc"},
+		{color.BlendSrcOver, halfRed, green, "#bcbc00"},
+		{color.BlendSrcOver, white, black, "#ffffff"},
+		{color.BlendMultiply, color.MustParse("#808080"), color.MustParse("#808080"), "#3d3d3d"},
+		{color.BlendScreen, color.MustParse("#808080"), black, "#808080"},
+		{color.BlendAdditive, color.MustParse("#808080"), color.MustParse("#808080"), "#b0b0b0"},
+		{color.BlendSrc, halfRed, green, "#ff000080"},
+		{color.BlendDst, halfRed, green, "#00ff00"},
+		{color.BlendClear, red, green, "#00000000"},
+	}
+
+	for _, c := range cases {
+		got := c.mode.BlendLinear(c.src, c.dst)
+		if !isNear8Bit(got, c.expected) {
+			t.Fatalf("Unexpected %v blend of %v over %v in linear light; \nExpected: %s; \nGot: %v",
+				c.mode, c.src, c.dst, c.expected, got)
+		}
+	}
+
+	// Without linear light the result stays the same as before
+	if got := color.BlendSrcOver.Blend(halfWhite, black); !isNear8Bit(got, "#808080") {
+		t.Fatalf("sRGB blending should give #808080, got %v", got)
+	}
+}
+
+func TestInLinearLight(t *testing.T) {
+	grad := color.GradientFromColor(color.MustParse("#000"))
+	grad.SetMark(color.GradientMark{Col: color.MustParse("#fff"), Pos: 1})
+
+	paints := []color.Paint{
+		grad,
+		color.NewLinearGradient(grad, image.Point{0, 0}, image.Point{10, 0}),
+	}
+	for _, paint := range paints {
+		if got := paint.ColorAt(5, 0, 0.5); !isNear8Bit(got, "#808080") {
+			t.Fatalf("sRGB midpoint of %T should be #808080, got %v", paint, got)
+		}
+		if got := color.InLinearLight(paint).ColorAt(5, 0, 0.5); !isNear8Bit(got, "#bcbcbc") {
+			t.Fatalf("Linear light midpoint of %T should be #bcbcbc, got %v", paint, got)
+		}
+	}
+
+	// Color spaces chosen explicitly are kept
+	grad.Space = color.SpaceOKLab
+	if got := color.InLinearLight(grad).(color.Gradient).Space; got != color.SpaceOKLab {
+		t.Fatalf("Space of the gradient should be kept, got %v", got)
+	}
+}
+
+// Allows the 8 bit channels to differ by 1, as the reference values are rounded
+func isNear8Bit(got color.Color, expected string) bool {
+	expectedCol := color.MustParse(expected)
+	r1, g1, b1, a1 := expectedCol.RGBA()
+	r2, g2, b2, a2 := got.RGBA()
+	for _, diff := range []int{int(r1) - int(r2), int(g1) - int(g2), int(b1) - int(b2), int(a1) - int(a2)} {
+		if diff > 0x101 || diff < -0x101 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,15 @@
+package color
+
+// Gives the color of every drawn pixel
+// Progress is the position of the pixel along the drawn shape and varies from 0 to 1,
+// each shape defines it on its own, so some paints may ignore it and use the coordinates instead
+type Paint interface {
+	ColorAt(x, y int, progress float32) Color
+	// If the paint always gives the same color, returns it, otherwise returns nil
+	ToPlainColor() *Color
+}
+
+// Implements Paint, the color depends only on the progress
+func (g Gradient) ColorAt(x, y int, progress float32) Color {
+	return g.GetMarkAt(progress).Col
+}
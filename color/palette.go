@@ -0,0 +1,279 @@
+package color
+
+import (
+	"image"
+	std_color "image/color"
+	"math"
+	"sort"
+)
+
+// Maps a color to the closest color it can represent
+// Implemented by Palette and Levels
+type Quantizer interface {
+	Nearest(c Color) Color
+}
+
+// Number of evenly spaced values every channel is reduced to, e.g. 256 for images with 8 bits per channel
+// Implements Quantizer
+type Levels int
+
+// Rounds every premultiplied channel to the closest level, less than 2 levels keep the color as it is
+func (l Levels) Nearest(c Color) Color {
+	if l < 2 {
+		return c
+	}
+
+	step := float64(math.MaxUint16) / float64(l-1)
+	round := func(val uint16) uint16 {
+		return uint16(math.Round(math.Round(float64(val)/step) * step))
+	}
+
+	a := round(c.A)
+	return Color{R: min(round(c.R), a), G: min(round(c.G), a), B: min(round(c.B), a), A: a}
+}
+
+// Fixed set of colors an image is reduced to
+// Colors are compared by their premultiplied channels, so the alpha is taken into account
+// Implements Quantizer
+type Palette []Color
+
+// Number of k-means iterations used when no better clusters are found earlier
+const DefaultKMeansIterations = 16
+
+// Gives the palette color closest to c, the first one wins a tie
+// Returns c if the palette is empty
+func (p Palette) Nearest(c Color) Color {
+	index := p.Index(c)
+	if index < 0 {
+		return c
+	}
+	return p[index]
+}
+
+// Gives the index of the palette color closest to c, -1 if the palette is empty
+func (p Palette) Index(c Color) int {
+	best := -1
+	var bestDist int64
+
+	for i, col := range p {
+		dist := getDistance(c, col)
+		if best < 0 || dist < bestDist {
+			best = i
+			bestDist = dist
+		}
+	}
+
+	return best
+}
+
+// Converts the palette for image.Paletted, e.g. to encode a GIF
+func (p Palette) ToStdPalette() std_color.Palette {
+	res := make(std_color.Palette, len(p))
+	for i, col := range p {
+		res[i] = col
+	}
+	return res
+}
+
+// Extracts at most count colors by splitting the colors of the image into boxes,
+// every box is split in half by the number of pixels along its widest channel
+// Images with fewer distinct colors give all of them
+// The result only depends on the pixels of the image
+func ExtractMedianCut(img image.Image, count int) Palette {
+	if count < 1 {
+		return Palette{}
+	}
+
+	boxes := []colorBox{{colors: getHistogram(img)}}
+	for len(boxes) < count {
+		widest := -1
+		for i, box := range boxes {
+			if len(box.colors) < 2 {
+				continue
+			}
+			if widest < 0 || box.getRange() > boxes[widest].getRange() {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+
+		left, right := boxes[widest].split()
+		boxes[widest] = left
+		boxes = append(boxes, right)
+	}
+
+	palette := make(Palette, 0, len(boxes))
+	for _, box := range boxes {
+		if len(box.colors) > 0 {
+			palette = append(palette, getMean(box.colors))
+		}
+	}
+	return palette
+}
+
+// Extracts at most count colors with k-means clustering, starting from the median cut palette
+// Stops when no pixel changes its cluster or after the number of iterations
+// The result only depends on the pixels of the image
+func ExtractKMeans(img image.Image, count int, iterations int) Palette {
+	histogram := getHistogram(img)
+	centers := ExtractMedianCut(img, count)
+	if len(centers) == 0 {
+		return centers
+	}
+
+	assignments := make([]int, len(histogram))
+	for i := range assignments {
+		assignments[i] = -1
+	}
+
+	for iteration := 0; iteration < iterations; iteration++ {
+		changed := false
+		for i, counted := range histogram {
+			index := centers.Index(counted.col)
+			if index != assignments[i] {
+				assignments[i] = index
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		clusters := make([][]countedColor, len(centers))
+		for i, counted := range histogram {
+			clusters[assignments[i]] = append(clusters[assignments[i]], counted)
+		}
+
+		// Empty clusters keep their centers
+		for i, cluster := range clusters {
+			if len(cluster) > 0 {
+				centers[i] = getMean(cluster)
+			}
+		}
+	}
+
+	return centers
+}
+
+type countedColor struct {
+	col   Color
+	count int
+}
+
+// Distinct colors of the image with the number of pixels, sorted by the channels
+func getHistogram(img image.Image) []countedColor {
+	counts := make(map[Color]int)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			counts[ColorFromStdColor(img.At(x, y))]++
+		}
+	}
+
+	histogram := make([]countedColor, 0, len(counts))
+	for col, count := range counts {
+		histogram = append(histogram, countedColor{col, count})
+	}
+
+	sort.Slice(histogram, func(i, j int) bool {
+		return isColorLess(histogram[i].col, histogram[j].col)
+	})
+	return histogram
+}
+
+type colorBox struct {
+	colors []countedColor
+}
+
+// Gives the index of the widest channel and its range
+func (b colorBox) getWidest() (int, int) {
+	minVals := getChannels(b.colors[0].col)
+	maxVals := minVals
+	for _, counted := range b.colors[1:] {
+		for i, val := range getChannels(counted.col) {
+			minVals[i] = min(minVals[i], val)
+			maxVals[i] = max(maxVals[i], val)
+		}
+	}
+
+	widest := 0
+	for i := range minVals {
+		if maxVals[i]-minVals[i] > maxVals[widest]-minVals[widest] {
+			widest = i
+		}
+	}
+	return widest, maxVals[widest] - minVals[widest]
+}
+
+func (b colorBox) getRange() int {
+	_, colorRange := b.getWidest()
+	return colorRange
+}
+
+// Splits the box at the median pixel of its widest channel, both parts are not empty
+func (b colorBox) split() (colorBox, colorBox) {
+	channel, _ := b.getWidest()
+
+	colors := make([]countedColor, len(b.colors))
+	copy(colors, b.colors)
+	sort.SliceStable(colors, func(i, j int) bool {
+		return getChannels(colors[i].col)[channel] < getChannels(colors[j].col)[channel]
+	})
+
+	total := 0
+	for _, counted := range colors {
+		total += counted.count
+	}
+
+	median := 1
+	seen := colors[0].count
+	for median < len(colors)-1 && seen*2 < total {
+		seen += colors[median].count
+		median++
+	}
+
+	return colorBox{colors[:median]}, colorBox{colors[median:]}
+}
+
+// Mean of the colors weighted by the number of pixels, rounded
+func getMean(colors []countedColor) Color {
+	var sums [4]int64
+	var total int64
+	for _, counted := range colors {
+		for i, val := range getChannels(counted.col) {
+			sums[i] += int64(val) * int64(counted.count)
+		}
+		total += int64(counted.count)
+	}
+
+	mean := func(sum int64) uint16 {
+		return uint16((sum + total/2) / total)
+	}
+	return Color{R: mean(sums[0]), G: mean(sums[1]), B: mean(sums[2]), A: mean(sums[3])}
+}
+
+func getChannels(c Color) [4]int {
+	return [4]int{int(c.R), int(c.G), int(c.B), int(c.A)}
+}
+
+func getDistance(c1, c2 Color) int64 {
+	var dist int64
+	channels1, channels2 := getChannels(c1), getChannels(c2)
+	for i := range channels1 {
+		diff := int64(channels1[i] - channels2[i])
+		dist += diff * diff
+	}
+	return dist
+}
+
+func isColorLess(c1, c2 Color) bool {
+	channels1, channels2 := getChannels(c1), getChannels(c2)
+	for i := range channels1 {
+		if channels1[i] != channels2[i] {
+			return channels1[i] < channels2[i]
+		}
+	}
+	return false
+}
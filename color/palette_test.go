@@ -0,0 +1,146 @@
+package color_test
+
+import (
+	"image"
+	"image/draw"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+)
+
+func TestExtractPalette(t *testing.T) {
+	cols := []color.Color{
+		color.MustParse("#000"),
+		color.MustParse("#f00"),
+		color.MustParse("#0f0"),
+		color.MustParse("#00f8"),
+	}
+	img := getQuartersImage(cols)
+
+	extractors := map[string]func(count int) color.Palette{
+		"median cut": func(count int) color.Palette {
+			return color.ExtractMedianCut(img, count)
+		},
+		"k-means": func(count int) color.Palette {
+			return color.ExtractKMeans(img, count, color.DefaultKMeansIterations)
+		},
+	}
+
+	for name, extract := range extractors {
+		// Image with 4 colors gives exactly these colors, even if more are asked for
+		for _, count := range []int{4, 10} {
+			palette := extract(count)
+			if !reflect.DeepEqual(sortColors(palette), sortColors(cols)) {
+				t.Fatalf("Unexpected %s palette of %d colors; \nExpected: %v; \nGot: %v", name, count, cols, palette)
+			}
+		}
+
+		palette := extract(2)
+		if len(palette) != 2 {
+			t.Fatalf("Expected %s palette of 2 colors, got %v", name, palette)
+		}
+		if !reflect.DeepEqual(palette, extract(2)) {
+			t.Fatalf("%s palette is not deterministic", name)
+		}
+
+		if palette := extract(0); len(palette) != 0 {
+			t.Fatalf("Expected an empty %s palette, got %v", name, palette)
+		}
+	}
+}
+
+func TestExtractKMeansImprovesMedianCut(t *testing.T) {
+	// Dark pixels outnumber the light ones, so the clusters should settle around both groups
+	img := image.NewRGBA(image.Rect(0, 0, 100, 1))
+	for x := 0; x < 100; x++ {
+		col := color.Color{R: uint16(x * 100), A: 0xffff}
+		if x >= 80 {
+			col = color.Color{R: 0xffff - uint16(100-x)*100, A: 0xffff}
+		}
+		img.Set(x, 0, col)
+	}
+
+	getError := func(palette color.Palette) int64 {
+		var res int64
+		for x := 0; x < 100; x++ {
+			col := color.ColorFromStdColor(img.At(x, 0))
+			diff := int64(col.R) - int64(palette.Nearest(col).R)
+			res += diff * diff
+		}
+		return res
+	}
+
+	medianCut := color.ExtractMedianCut(img, 2)
+	kMeans := color.ExtractKMeans(img, 2, color.DefaultKMeansIterations)
+	if getError(kMeans) > getError(medianCut) {
+		t.Fatalf("K-means palette %v has a greater error than the median cut palette %v", kMeans, medianCut)
+	}
+}
+
+func TestPaletteNearest(t *testing.T) {
+	palette := color.Palette{color.MustParse("#000"), color.MustParse("#fff"), color.MustParse("#f00")}
+
+	cases := map[string]int{
+		"#111":      0,
+		"#eee":      1,
+		"#c33":      2,
+		"#80808000": 0,
+	}
+	for text, expected := range cases {
+		if got := palette.Index(color.MustParse(text)); got != expected {
+			t.Fatalf("Unexpected nearest color of %s; \nExpected: %v; \nGot: %v", text, palette[expected], palette[got])
+		}
+	}
+
+	if got := (color.Palette{}).Index(color.MustParse("#fff")); got != -1 {
+		t.Fatalf("Empty palette should give -1, got %d", got)
+	}
+	if got := len(palette.ToStdPalette()); got != len(palette) {
+		t.Fatalf("Expected %d colors in the standard palette, got %d", len(palette), got)
+	}
+}
+
+// Image split into 4 quarters with a color each
+func getQuartersImage(cols []color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	quarters := []image.Rectangle{
+		image.Rect(0, 0, 10, 10),
+		image.Rect(10, 0, 20, 10),
+		image.Rect(0, 10, 10, 20),
+		image.Rect(10, 10, 20, 20),
+	}
+	for i, quarter := range quarters {
+		draw.Draw(img, quarter, &image.Uniform{cols[i]}, image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func sortColors(cols []color.Color) []color.Color {
+	sorted := append([]color.Color{}, cols...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+	return sorted
+}
+
+func TestLevelsNearest(t *testing.T) {
+	cases := []struct {
+		levels   color.Levels
+		col      color.Color
+		expected color.Color
+	}{
+		{256, color.Color{R: 0x8080 + 0x70, A: 0xffff}, color.Color{R: 0x8080, A: 0xffff}},
+		{256, color.Color{R: 0x8080 + 0x90, A: 0xffff}, color.Color{R: 0x8181, A: 0xffff}},
+		{2, color.Color{R: 0x7000, G: 0x9000, B: 0xffff, A: 0xffff}, color.Color{G: 0xffff, B: 0xffff, A: 0xffff}},
+		{2, color.Color{R: 0x7000, A: 0x9000}, color.Color{R: 0, A: 0xffff}},
+		{1, color.Color{R: 0x1234, A: 0x5678}, color.Color{R: 0x1234, A: 0x5678}},
+	}
+
+	for _, c := range cases {
+		if got := c.levels.Nearest(c.col); got != c.expected {
+			t.Fatalf("Unexpected color of %v with %d levels; \nExpected: %v; \nGot: %v", c.col, c.levels, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package color
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type InvalidColor struct {
+	Text   string
+	Reason string
+}
+
+func (invalidColor InvalidColor) Error() string {
+	return fmt.Sprintf("Invalid color %q: %s", invalidColor.Text, invalidColor.Reason)
+}
+
+// Parses a CSS color: #rgb, #rgba, #rrggbb, #rrggbbaa, rgb(), rgba(), hsl(), hsla(),
+// a named color or "transparent"
+// Both comma and space separated function arguments are supported, values out of range are clamped
+// Returns InvalidColor if the text is not a color
+func Parse(text string) (Color, error) {
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	invalid := func(reason string) (Color, error) {
+		return Color{}, InvalidColor{Text: text, Reason: reason}
+	}
+
+	if normalized == "" {
+		return invalid("empty string")
+	}
+
+	if strings.HasPrefix(normalized, "#") {
+		col, ok := parseHex(normalized[1:])
+		if !ok {
+			return invalid("expected 3, 4, 6 or 8 hex digits after #")
+		}
+		return col, nil
+	}
+
+	if open := strings.IndexByte(normalized, '('); open >= 0 {
+		if !strings.HasSuffix(normalized, ")") {
+			return invalid("missing closing parenthesis")
+		}
+
+		name := strings.TrimSpace(normalized[:open])
+		args := normalized[open+1 : len(normalized)-1]
+		col, reason := parseFunction(name, args)
+		if reason != "" {
+			return invalid(reason)
+		}
+		return col, nil
+	}
+
+	if normalized == "transparent" {
+		return Color{}, nil
+	}
+
+	if rgb, ok := namedColors[normalized]; ok {
+		return fromRGB8(uint8(rgb>>16), uint8(rgb>>8), uint8(rgb), math.MaxUint8), nil
+	}
+
+	return invalid("unknown color name")
+}
+
+// Same as Parse, but panics if the text is not a color
+// Meant for colors known at compile time
+func MustParse(text string) Color {
+	col, err := Parse(text)
+	if err != nil {
+		panic(err)
+	}
+	return col
+}
+
+// Formats the color as #rrggbb, or as #rrggbbaa if the color is not opaque
+// The result can be parsed back with Parse, precision is lowered to 8 bits per channel
+func (c Color) String() string {
+	straight := c.toStraight()
+	to8 := func(val float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, val)) * math.MaxUint8))
+	}
+
+	r, g, b, a := to8(straight.r), to8(straight.g), to8(straight.b), to8(straight.a)
+	if a == math.MaxUint8 {
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", r, g, b, a)
+}
+
+func fromRGB8(r, g, b, a uint8) Color {
+	return straightColor{
+		r: float64(r) / math.MaxUint8,
+		g: float64(g) / math.MaxUint8,
+		b: float64(b) / math.MaxUint8,
+		a: float64(a) / math.MaxUint8,
+	}.toPremultiplied()
+}
+
+func parseHex(digits string) (Color, bool) {
+	for _, digit := range digits {
+		if !strings.ContainsRune("0123456789abcdef", digit) {
+			return Color{}, false
+		}
+	}
+
+	// Short forms repeat every digit
+	if len(digits) == 3 || len(digits) == 4 {
+		long := make([]byte, 0, len(digits)*2)
+		for i := range digits {
+			long = append(long, digits[i], digits[i])
+		}
+		digits = string(long)
+	}
+
+	if len(digits) == 6 {
+		digits += "ff"
+	}
+	if len(digits) != 8 {
+		return Color{}, false
+	}
+
+	val, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return Color{}, false
+	}
+
+	return fromRGB8(uint8(val>>24), uint8(val>>16), uint8(val>>8), uint8(val)), true
+}
+
+// Returns a non empty reason if the arguments are invalid
+func parseFunction(name, args string) (Color, string) {
+	values, alpha, reason := splitArguments(args)
+	if reason != "" {
+		return Color{}, reason
+	}
+
+	straight := straightColor{a: 1}
+	if alpha != "" {
+		a, ok := parseAlpha(alpha)
+		if !ok {
+			return Color{}, fmt.Sprintf("invalid alpha %q", alpha)
+		}
+		straight.a = a
+	}
+
+	switch name {
+	case "rgb", "rgba":
+		channels := make([]float64, 3)
+		for i, val := range values {
+			channel, ok := parseRGBChannel(val)
+			if !ok {
+				return Color{}, fmt.Sprintf("invalid %s channel %q", name, val)
+			}
+			channels[i] = channel
+		}
+		straight.r, straight.g, straight.b = channels[0], channels[1], channels[2]
+
+	case "hsl", "hsla":
+		hue, ok := parseHue(values[0])
+		if !ok {
+			return Color{}, fmt.Sprintf("invalid hue %q", values[0])
+		}
+		saturation, ok := parsePercentage(values[1])
+		if !ok {
+			return Color{}, fmt.Sprintf("invalid saturation %q", values[1])
+		}
+		lightness, ok := parsePercentage(values[2])
+		if !ok {
+			return Color{}, fmt.Sprintf("invalid lightness %q", values[2])
+		}
+
+		rgb := hslToRGB([3]float64{hue, saturation, lightness})
+		straight.r, straight.g, straight.b = rgb.r, rgb.g, rgb.b
+
+	default:
+		return Color{}, fmt.Sprintf("unknown function %q", name)
+	}
+
+	return straight.toPremultiplied(), ""
+}
+
+// Splits "1, 2, 3, 0.5", "1 2 3 / 0.5" and similar into 3 values and an optional alpha
+func splitArguments(args string) (values []string, alpha, reason string) {
+	if before, after, found := strings.Cut(args, "/"); found {
+		args = before
+		alpha = strings.TrimSpace(after)
+		if alpha == "" {
+			return nil, "", "missing alpha after /"
+		}
+	}
+
+	values = strings.Fields(strings.ReplaceAll(args, ",", " "))
+	if len(values) == 4 && alpha == "" {
+		alpha = values[3]
+		values = values[:3]
+	}
+
+	if len(values) != 3 {
+		return nil, "", fmt.Sprintf("expected 3 values and an optional alpha, got %d values", len(values))
+	}
+
+	return values, alpha, ""
+}
+
+// Number from 0 to 255 or a percentage, gives a value from 0 to 1
+func parseRGBChannel(text string) (float64, bool) {
+	if strings.HasSuffix(text, "%") {
+		return parsePercentage(text)
+	}
+
+	val, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, false
+	}
+	return clamp01(val / math.MaxUint8), true
+}
+
+// Number from 0 to 1 or a percentage
+func parseAlpha(text string) (float64, bool) {
+	if strings.HasSuffix(text, "%") {
+		return parsePercentage(text)
+	}
+
+	val, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, false
+	}
+	return clamp01(val), true
+}
+
+// Percentage or a plain number from 0 to 100, gives a value from 0 to 1
+func parsePercentage(text string) (float64, bool) {
+	val, err := strconv.ParseFloat(strings.TrimSuffix(text, "%"), 64)
+	if err != nil {
+		return 0, false
+	}
+	return clamp01(val / 100), true
+}
+
+// Degrees by default, deg, rad, grad and turn units are supported
+func parseHue(text string) (float64, bool) {
+	units := []struct {
+		suffix  string
+		degrees float64
+	}{
+		{"deg", 1},
+		{"grad", 0.9},
+		{"rad", 180 / math.Pi},
+		{"turn", 360},
+	}
+
+	scale := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(text, unit.suffix) {
+			text = strings.TrimSuffix(text, unit.suffix)
+			scale = unit.degrees
+			break
+		}
+	}
+
+	val, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	hue := math.Mod(val*scale, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	return hue, true
+}
+
+func clamp01(val float64) float64 {
+	return math.Max(0, math.Min(1, val))
+}
@@ -0,0 +1,378 @@
+package color
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type InvalidGradient struct {
+	Text   string
+	Reason string
+}
+
+func (invalidGradient InvalidGradient) Error() string {
+	return fmt.Sprintf("Invalid gradient %q: %s", invalidGradient.Text, invalidGradient.Reason)
+}
+
+// Parses a gradient written similar to a CSS linear-gradient:
+//
+//	linear([options,] <color> [<position>] [<easing>], [<hint>,] <color> [<position>] [<easing>], ...)
+//
+// Options are "in <space> [shorter|longer]" and a spread mode "pad", "repeat" or "reflect", e.g. "in oklch repeat"
+// Positions are percentages or numbers from 0 to 1, missing positions are spread evenly between known ones
+// Easing of a mark is one of "linear", "ease-in", "ease-out", "ease-in-out", "hold",
+// "steps(<count>)" or "cubic-bezier(<x1>, <y1>, <x2>, <y2>)"
+// A hint is a single position between two marks where their colors are mixed equally
+// Marks are sorted by their positions, marks with the same position make a hard stop
+// Returns InvalidGradient if the text is not a gradient
+func ParseGradient(text string) (Gradient, error) {
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	invalid := func(reason string) (Gradient, error) {
+		return Gradient{}, InvalidGradient{Text: text, Reason: reason}
+	}
+
+	if !strings.HasPrefix(normalized, "linear(") || !strings.HasSuffix(normalized, ")") {
+		return invalid(`expected "linear(...)"`)
+	}
+
+	args := splitTopLevel(normalized[len("linear("):len(normalized)-1], ',')
+	grad := Gradient{}
+
+	if len(args) > 0 {
+		isOptions, reason := grad.parseOptions(args[0])
+		if reason != "" {
+			return invalid(reason)
+		}
+		if isOptions {
+			args = args[1:]
+		}
+	}
+
+	marks, reason := parseMarks(args)
+	if reason != "" {
+		return invalid(reason)
+	}
+
+	if len(marks) == 1 {
+		grad.Marks = GradientFromColor(marks[0].Col).Marks
+		return grad, nil
+	}
+
+	grad.Marks = marks
+	return grad, nil
+}
+
+// Formats the gradient in the syntax read by ParseGradient
+func (g Gradient) String() string {
+	args := make([]string, 0, len(g.Marks)*2+1)
+
+	options := make([]string, 0, 3)
+	if g.Space != SpaceSRGB {
+		options = append(options, "in "+g.Space.String())
+		if g.Hue != HueShorter && g.Space.getHueIndex() >= 0 {
+			options = append(options, g.Hue.String())
+		}
+	}
+	if g.Spread != SpreadPad {
+		options = append(options, g.Spread.String())
+	}
+	if len(options) > 0 {
+		args = append(args, strings.Join(options, " "))
+	}
+
+	for i, mark := range g.Marks {
+		stop := mark.Col.String() + " " + formatPosition(mark.Pos)
+
+		isLast := i == len(g.Marks)-1
+		switch {
+		case isLast || mark.Easing.Kind == EaseLinear:
+		case mark.Easing.Kind == EaseHint:
+			next := g.Marks[i+1]
+			hint := mark.Pos + mark.Easing.Hint*(next.Pos-mark.Pos)
+			args = append(args, stop)
+			stop = formatPosition(hint)
+		default:
+			stop += " " + mark.Easing.String()
+		}
+
+		args = append(args, stop)
+	}
+
+	return "linear(" + strings.Join(args, ", ") + ")"
+}
+
+func formatFloat(val float32) string {
+	return strconv.FormatFloat(float64(val), 'f', -1, 32)
+}
+
+// Rounded to the precision of float32, so positions like 0.6 are not written as 60.000004%
+func formatPosition(pos float32) string {
+	percent := math.Round(float64(pos)*100*1e4) / 1e4
+	return strconv.FormatFloat(percent, 'f', -1, 64) + "%"
+}
+
+// Reports if the argument is a list of options, returns a non empty reason if the options are invalid
+func (g *Gradient) parseOptions(arg string) (isOptions bool, reason string) {
+	words := strings.Fields(arg)
+	if len(words) == 0 {
+		return false, ""
+	}
+
+	isSpread := func(word string) bool {
+		return word == "pad" || word == "repeat" || word == "reflect"
+	}
+	if words[0] != "in" && !isSpread(words[0]) {
+		return false, ""
+	}
+
+	for i := 0; i < len(words); i++ {
+		switch word := words[i]; {
+		case word == "in":
+			if i+1 >= len(words) {
+				return true, "missing color space after \"in\""
+			}
+			i++
+			space, ok := findName(colorSpaceNames, words[i])
+			if !ok {
+				return true, fmt.Sprintf("unknown color space %q", words[i])
+			}
+			g.Space = space
+
+		case word == "shorter" || word == "longer":
+			g.Hue, _ = findName(hueInterpolationNames, word)
+
+		case isSpread(word):
+			g.Spread, _ = findName(spreadModeNames, word)
+
+		default:
+			return true, fmt.Sprintf("unknown option %q", word)
+		}
+	}
+
+	return true, ""
+}
+
+func findName[T comparable](names map[T]string, name string) (T, bool) {
+	for val, valName := range names {
+		if valName == name {
+			return val, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// A mark which position may be missing
+type parsedMark struct {
+	mark        GradientMark
+	hasPosition bool
+	// Absolute position of the hint after this mark
+	hint    float32
+	hasHint bool
+}
+
+func parseMarks(args []string) ([]GradientMark, string) {
+	parsed := make([]parsedMark, 0, len(args))
+
+	for _, arg := range args {
+		tokens := splitTopLevel(arg, ' ')
+		if len(tokens) == 0 {
+			return nil, "empty mark"
+		}
+
+		// A single position is a hint
+		if len(tokens) == 1 {
+			if pos, ok := parsePosition(tokens[0]); ok {
+				if len(parsed) == 0 || parsed[len(parsed)-1].hasHint {
+					return nil, fmt.Sprintf("hint %q should be between two marks", tokens[0])
+				}
+				parsed[len(parsed)-1].hint = pos
+				parsed[len(parsed)-1].hasHint = true
+				continue
+			}
+		}
+
+		if len(tokens) > 3 {
+			return nil, fmt.Sprintf("too many values in mark %q", arg)
+		}
+
+		col, err := Parse(tokens[0])
+		if err != nil {
+			return nil, err.Error()
+		}
+
+		current := parsedMark{mark: GradientMark{Col: col}}
+		for _, token := range tokens[1:] {
+			if pos, ok := parsePosition(token); ok && !current.hasPosition {
+				current.mark.Pos = pos
+				current.hasPosition = true
+				continue
+			}
+
+			easing, ok := parseEasing(token)
+			if !ok {
+				return nil, fmt.Sprintf("invalid position or easing %q", token)
+			}
+			current.mark.Easing = easing
+		}
+
+		if current.hasPosition && (current.mark.Pos < 0 || current.mark.Pos > 1) {
+			return nil, fmt.Sprintf("position of mark %q is outside of the range from 0%% to 100%%", arg)
+		}
+		parsed = append(parsed, current)
+	}
+
+	if len(parsed) == 0 {
+		return nil, "no marks"
+	}
+	if parsed[len(parsed)-1].hasHint {
+		return nil, "hint should be between two marks"
+	}
+
+	fillMissingPositions(parsed)
+
+	// Hints become easings once the positions are known
+	sort.SliceStable(parsed, func(i, j int) bool {
+		return parsed[i].mark.Pos < parsed[j].mark.Pos
+	})
+
+	marks := make([]GradientMark, len(parsed))
+	for i, p := range parsed {
+		marks[i] = p.mark
+		if p.hasHint && i+1 < len(parsed) {
+			next := parsed[i+1].mark.Pos
+			if p.hint < p.mark.Pos || p.hint > next {
+				return nil, fmt.Sprintf("hint %s is not between its marks", formatPosition(p.hint))
+			}
+			if next > p.mark.Pos {
+				marks[i].Easing = Easing{Kind: EaseHint, Hint: (p.hint - p.mark.Pos) / (next - p.mark.Pos)}
+			}
+		}
+	}
+
+	return marks, ""
+}
+
+// First and last marks default to 0 and 1, others are spread evenly between known positions
+func fillMissingPositions(parsed []parsedMark) {
+	if !parsed[0].hasPosition {
+		parsed[0].mark.Pos = 0
+		parsed[0].hasPosition = true
+	}
+	last := len(parsed) - 1
+	if !parsed[last].hasPosition {
+		parsed[last].mark.Pos = 1
+		parsed[last].hasPosition = true
+	}
+
+	known := 0
+	for i := 1; i <= last; i++ {
+		if !parsed[i].hasPosition {
+			continue
+		}
+
+		start := parsed[known].mark.Pos
+		step := (parsed[i].mark.Pos - start) / float32(i-known)
+		for j := known + 1; j < i; j++ {
+			parsed[j].mark.Pos = start + step*float32(j-known)
+			parsed[j].hasPosition = true
+		}
+		known = i
+	}
+}
+
+// Percentage or a number from 0 to 1
+func parsePosition(text string) (float32, bool) {
+	if strings.HasSuffix(text, "%") {
+		val, err := strconv.ParseFloat(strings.TrimSuffix(text, "%"), 32)
+		if err != nil {
+			return 0, false
+		}
+		return float32(val / 100), true
+	}
+
+	val, err := strconv.ParseFloat(text, 32)
+	if err != nil {
+		return 0, false
+	}
+	return float32(val), true
+}
+
+func parseEasing(text string) (Easing, bool) {
+	name, args, isFunction := strings.Cut(text, "(")
+	if !isFunction {
+		kind, ok := findName(easingKindNames, text)
+		if !ok || kind == EaseCubicBezier || kind == EaseSteps || kind == EaseHint {
+			return Easing{}, false
+		}
+		return Easing{Kind: kind}, true
+	}
+
+	if !strings.HasSuffix(args, ")") {
+		return Easing{}, false
+	}
+	values := strings.Fields(strings.ReplaceAll(strings.TrimSuffix(args, ")"), ",", " "))
+
+	switch name {
+	case "steps":
+		if len(values) != 1 {
+			return Easing{}, false
+		}
+		steps, err := strconv.Atoi(values[0])
+		if err != nil || steps < 1 {
+			return Easing{}, false
+		}
+		return Easing{Kind: EaseSteps, Steps: steps}, true
+
+	case "cubic-bezier":
+		if len(values) != 4 {
+			return Easing{}, false
+		}
+		points := make([]float32, 4)
+		for i, val := range values {
+			point, err := strconv.ParseFloat(val, 32)
+			if err != nil {
+				return Easing{}, false
+			}
+			points[i] = float32(point)
+		}
+		return Easing{Kind: EaseCubicBezier, X1: points[0], Y1: points[1], X2: points[2], Y2: points[3]}, true
+
+	default:
+		return Easing{}, false
+	}
+}
+
+// Splits the text by the separator, ignoring separators inside of parentheses
+// Parts are trimmed and empty parts are skipped when splitting by spaces
+func splitTopLevel(text string, separator rune) []string {
+	parts := make([]string, 0)
+	depth := 0
+	start := 0
+
+	addPart := func(end int) {
+		part := strings.TrimSpace(text[start:end])
+		if part != "" || separator != ' ' {
+			parts = append(parts, part)
+		}
+	}
+
+	for i, char := range text {
+		switch {
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == separator && depth == 0:
+			addPart(i)
+			start = i + 1
+		}
+	}
+	addPart(len(text))
+
+	return parts
+}
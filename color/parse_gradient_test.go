@@ -0,0 +1,134 @@
+package color_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+)
+
+func TestParseGradient(t *testing.T) {
+	grad, err := color.ParseGradient("linear(#000 0%, #f00 40%, #fff 100%)")
+	if err != nil {
+		t.Fatalf("Cannot parse gradient: %v", err)
+	}
+
+	expected := []color.GradientMark{
+		{Col: color.MustParse("#000"), Pos: 0},
+		{Col: color.MustParse("#f00"), Pos: 0.4},
+		{Col: color.MustParse("#fff"), Pos: 1},
+	}
+	if !reflect.DeepEqual(grad.Marks, expected) {
+		t.Fatalf("Unexpected marks; \nExpected: %v; \nGot: %v", expected, grad.Marks)
+	}
+}
+
+func TestParseGradientSortsAndFillsPositions(t *testing.T) {
+	grad, err := color.ParseGradient("linear(red, rgb(0, 255, 0), blue 0.2, white)")
+	if err != nil {
+		t.Fatalf("Cannot parse gradient: %v", err)
+	}
+
+	// Red is at 0, green is spread to 0.1, white defaults to 1, blue is moved between red and green
+	expected := []float32{0, 0.1, 0.2, 1}
+	cols := []color.Color{color.MustParse("red"), color.MustParse("lime"), color.MustParse("blue"), color.MustParse("white")}
+	if len(grad.Marks) != len(expected) {
+		t.Fatalf("Expected %d marks, got %v", len(expected), grad.Marks)
+	}
+	for i, mark := range grad.Marks {
+		if mark.Pos != expected[i] || mark.Col != cols[i] {
+			t.Fatalf("Unexpected mark %d; \nExpected: %v at %v; \nGot: %v", i, cols[i], expected[i], mark)
+		}
+	}
+
+	grad, err = color.ParseGradient("linear(white 100%, black 0%)")
+	if err != nil {
+		t.Fatalf("Cannot parse gradient: %v", err)
+	}
+	if grad.Marks[0].Col != color.MustParse("black") || grad.Marks[1].Col != color.MustParse("white") {
+		t.Fatalf("Marks are not sorted: %v", grad.Marks)
+	}
+}
+
+func TestParseGradientOptionsAndEasings(t *testing.T) {
+	grad, err := color.ParseGradient(
+		"linear(in OKLCh longer reflect, #000 ease-in, 25%, #f00 50%, #0f0 60% cubic-bezier(0.1, 0.2, 0.3, 1), #00f 80% steps(3), #fff)")
+	if err != nil {
+		t.Fatalf("Cannot parse gradient: %v", err)
+	}
+
+	if grad.Space != color.SpaceOKLCh || grad.Hue != color.HueLonger || grad.Spread != color.SpreadReflect {
+		t.Fatalf("Unexpected options: %v %v %v", grad.Space, grad.Hue, grad.Spread)
+	}
+
+	expected := []color.Easing{
+		{Kind: color.EaseHint, Hint: 0.5},
+		{},
+		{Kind: color.EaseCubicBezier, X1: 0.1, Y1: 0.2, X2: 0.3, Y2: 1},
+		{Kind: color.EaseSteps, Steps: 3},
+		{},
+	}
+	for i, mark := range grad.Marks {
+		if mark.Easing != expected[i] {
+			t.Fatalf("Unexpected easing of mark %d; \nExpected: %v; \nGot: %v", i, expected[i], mark.Easing)
+		}
+	}
+}
+
+func TestGradientStringRoundTrip(t *testing.T) {
+	texts := []string{
+		"linear(#000000 0%, #ff0000 40%, #ffffff 100%)",
+		"linear(in oklch longer repeat, #000000 0%, #ff000080 25% ease-in-out, #00ff00 60% steps(4), #ffffff 100%)",
+		"linear(in lab, #000000 0%, 30%, #ffffff 100%)",
+		"linear(reflect, #000000 0% cubic-bezier(0.25, 0.1, 0.25, 1), #ffffff 50% hold, #000000 50%, #ffffff 100%)",
+	}
+
+	for _, text := range texts {
+		grad, err := color.ParseGradient(text)
+		if err != nil {
+			t.Fatalf("Cannot parse %q: %v", text, err)
+		}
+
+		if got := grad.String(); got != text {
+			t.Fatalf("Gradient is not formatted back; \nExpected: %s; \nGot: %s", text, got)
+		}
+
+		again, err := color.ParseGradient(grad.String())
+		if err != nil {
+			t.Fatalf("Cannot parse formatted %q: %v", grad.String(), err)
+		}
+		if !reflect.DeepEqual(grad, again) {
+			t.Fatalf("Gradient changed after formatting; \nExpected: %v; \nGot: %v", grad, again)
+		}
+	}
+}
+
+func TestParseGradientErrors(t *testing.T) {
+	invalid := []string{
+		"",
+		"radial(#000, #fff)",
+		"linear(#000, #fff",
+		"linear()",
+		"linear(#000 -10%, #fff)",
+		"linear(#000, #fff 150%)",
+		"linear(#000, notacolor)",
+		"linear(#000 0% bounce, #fff)",
+		"linear(#000 0% 10% 20%, #fff)",
+		"linear(50%, #000, #fff)",
+		"linear(#000, 50%, 60%, #fff)",
+		"linear(#000, #fff, 50%)",
+		"linear(#000 50%, 10%, #fff)",
+		"linear(in cmyk, #000, #fff)",
+		"linear(repeat sideways, #000, #fff)",
+		"linear(#000 0% steps(0), #fff)",
+	}
+
+	for _, text := range invalid {
+		_, err := color.ParseGradient(text)
+		var invalidGradient color.InvalidGradient
+		if !errors.As(err, &invalidGradient) {
+			t.Fatalf("Expected InvalidGradient for %q, got %v", text, err)
+		}
+	}
+}
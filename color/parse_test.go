@@ -0,0 +1,87 @@
+package color_test
+
+import (
+	"errors"
+	std_color "image/color"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+)
+
+func TestParseColor(t *testing.T) {
+	cases := map[string]std_color.Color{
+		"#f00":                          std_color.RGBA{255, 0, 0, 255},
+		"#F00":                          std_color.RGBA{255, 0, 0, 255},
+		"#00ff0080":                     std_color.NRGBA{0, 255, 0, 128},
+		"#0008":                         std_color.NRGBA{0, 0, 0, 136},
+		"  #123456 ":                    std_color.RGBA{0x12, 0x34, 0x56, 255},
+		"rgb(255, 128, 0)":              std_color.RGBA{255, 128, 0, 255},
+		"rgb(100% 50% 0%)":              std_color.RGBA{255, 128, 0, 255},
+		"rgba(0, 0, 255, 0.5)":          std_color.NRGBA{0, 0, 255, 128},
+		"rgb(0 0 255 / 50%)":            std_color.NRGBA{0, 0, 255, 128},
+		"rgb(300, -5, 0)":               std_color.RGBA{255, 0, 0, 255},
+		"hsl(120, 100%, 50%)":           std_color.RGBA{0, 255, 0, 255},
+		"hsl(0.5turn 100% 25%)":         std_color.RGBA{0, 128, 128, 255},
+		"hsla(240deg, 100%, 50%, 0.25)": std_color.NRGBA{0, 0, 255, 64},
+		"rebeccapurple":                 std_color.RGBA{0x66, 0x33, 0x99, 255},
+		"White":                         std_color.RGBA{255, 255, 255, 255},
+		"transparent":                   std_color.RGBA{},
+	}
+
+	for text, expected := range cases {
+		got, err := color.Parse(text)
+		if err != nil {
+			t.Fatalf("Cannot parse %q: %v", text, err)
+		}
+
+		expectedCol := color.ColorFromStdColor(expected)
+		r1, g1, b1, a1 := expectedCol.RGBA()
+		r2, g2, b2, a2 := got.RGBA()
+		// Allows for rounding of the 8 bit values
+		for _, diff := range []int{int(r1) - int(r2), int(g1) - int(g2), int(b1) - int(b2), int(a1) - int(a2)} {
+			if diff > 256 || diff < -256 {
+				t.Fatalf("Unexpected color parsed from %q; \nExpected: %v; \nGot: %v", text, expectedCol, got)
+			}
+		}
+	}
+}
+
+func TestParseColorErrors(t *testing.T) {
+	invalid := []string{
+		"",
+		"#12",
+		"#12345g",
+		"rgb(1, 2)",
+		"rgb(1, 2, 3",
+		"rgb(a, b, c)",
+		"hsl(10, 20%, x)",
+		"cmyk(1, 2, 3)",
+		"notacolor",
+		"rgb(1 2 3 /)",
+	}
+
+	for _, text := range invalid {
+		_, err := color.Parse(text)
+		var invalidColor color.InvalidColor
+		if !errors.As(err, &invalidColor) {
+			t.Fatalf("Expected InvalidColor when parsing %q, got %v", text, err)
+		}
+		if invalidColor.Text != text {
+			t.Fatalf("Error should contain the parsed text %q, got %q", text, invalidColor.Text)
+		}
+	}
+}
+
+func TestColorString(t *testing.T) {
+	cases := []string{"#ff8000", "#00000000", "#12345680", "#ffffff"}
+	for _, text := range cases {
+		col := color.MustParse(text)
+		if col.String() != text {
+			t.Fatalf("Color parsed from %q is formatted as %q", text, col.String())
+		}
+	}
+
+	if got := (color.Color{R: 0xffff, A: 0xffff}).String(); got != "#ff0000" {
+		t.Fatalf("Unexpected string for red; \nExpected: #ff0000; \nGot: %v", got)
+	}
+}
@@ -0,0 +1,61 @@
+package color
+
+import (
+	"image"
+	"math"
+)
+
+// Marks go from the focal point (position 0) to the circle around the center (position 1)
+// The focal point is moved inside of the circle if it is outside
+// Implements Paint, the color depends only on the coordinates of a pixel
+type RadialGradient struct {
+	Gradient
+	Center image.Point
+	Focal  image.Point
+	Radius float64
+}
+
+// Gives a radial gradient with the focal point in the center
+func NewRadialGradient(grad Gradient, center image.Point, radius float64) RadialGradient {
+	return RadialGradient{
+		Gradient: grad,
+		Center:   center,
+		Focal:    center,
+		Radius:   radius,
+	}
+}
+
+func (g RadialGradient) ColorAt(x, y int, progress float32) Color {
+	return g.GetMarkAt(g.GetProgress(x, y)).Col
+}
+
+// Position of the pixel between the focal point and the circle, from 0 to 1
+func (g RadialGradient) GetProgress(x, y int) float32 {
+	if g.Radius <= 0 {
+		return 1
+	}
+
+	centerX, centerY := float64(g.Center.X), float64(g.Center.Y)
+	focalX, focalY := float64(g.Focal.X)-centerX, float64(g.Focal.Y)-centerY
+
+	// Keeps the focal point strictly inside, otherwise parts of the plane have no progress
+	maxFocal := g.Radius * 0.99
+	if focalDist := math.Hypot(focalX, focalY); focalDist > maxFocal {
+		focalX *= maxFocal / focalDist
+		focalY *= maxFocal / focalDist
+	}
+
+	dirX := float64(x) - centerX - focalX
+	dirY := float64(y) - centerY - focalY
+	if dirX == 0 && dirY == 0 {
+		return 0
+	}
+
+	// The ray from the focal point through the pixel hits the circle at focal + dir * s
+	a := dirX*dirX + dirY*dirY
+	b := 2 * (focalX*dirX + focalY*dirY)
+	c := focalX*focalX + focalY*focalY - g.Radius*g.Radius
+	s := (-b + math.Sqrt(b*b-4*a*c)) / (2 * a)
+
+	return float32(1 / s)
+}
@@ -0,0 +1,61 @@
+package color_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+)
+
+func TestRadialGradientProgress(t *testing.T) {
+	grad := color.NewRadialGradient(getBlackToWhite(), image.Point{100, 100}, 50)
+
+	cases := map[image.Point]float32{
+		{100, 100}: 0,
+		{125, 100}: 0.5,
+		{100, 75}:  0.5,
+		{150, 100}: 1,
+		{100, 50}:  1,
+	}
+
+	for p, expected := range cases {
+		got := grad.GetProgress(p.X, p.Y)
+		if math.Abs(float64(got-expected)) > 0.001 {
+			t.Fatalf("[%d;%d] unexpected progress; \nExpected: %v; \nGot: %v", p.X, p.Y, expected, got)
+		}
+	}
+}
+
+func TestRadialGradientFocal(t *testing.T) {
+	grad := color.NewRadialGradient(getBlackToWhite(), image.Point{100, 100}, 50)
+	grad.Focal = image.Point{120, 100}
+
+	cases := map[image.Point]float32{
+		{120, 100}: 0,
+		{150, 100}: 1,
+		{50, 100}:  1,
+		{135, 100}: 0.5,
+		{85, 100}:  0.5,
+	}
+
+	for p, expected := range cases {
+		got := grad.GetProgress(p.X, p.Y)
+		if math.Abs(float64(got-expected)) > 0.001 {
+			t.Fatalf("[%d;%d] unexpected progress; \nExpected: %v; \nGot: %v", p.X, p.Y, expected, got)
+		}
+	}
+
+	if grad.ColorAt(120, 100, 1) != grad.Marks[0].Col {
+		t.Fatalf("Radial gradient should ignore the progress of a shape")
+	}
+}
+
+func getBlackToWhite() color.Gradient {
+	grad := color.GradientFromColor(color.Color{A: math.MaxUint16})
+	grad.SetMark(color.GradientMark{
+		Col: color.Color{R: math.MaxUint16, G: math.MaxUint16, B: math.MaxUint16, A: math.MaxUint16},
+		Pos: 1,
+	})
+	return grad
+}
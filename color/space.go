@@ -0,0 +1,431 @@
+package color
+
+import (
+	"math"
+)
+
+// Color space in which a gradient is interpolated between marks
+type ColorSpace int
+
+const (
+	// Alpha-premultiplied sRGB values are interpolated directly
+	SpaceSRGB ColorSpace = iota
+	SpaceLinearRGB
+	SpaceHSL
+	SpaceHSV
+	// CIE L*a*b* with the D65 white point
+	SpaceLab
+	// Cylindrical form of CIE L*a*b*
+	SpaceLCh
+	SpaceOKLab
+	// Cylindrical form of OKLab
+	SpaceOKLCh
+)
+
+var colorSpaceNames = map[ColorSpace]string{
+	SpaceSRGB:      "srgb",
+	SpaceLinearRGB: "linear-rgb",
+	SpaceHSL:       "hsl",
+	SpaceHSV:       "hsv",
+	SpaceLab:       "lab",
+	SpaceLCh:       "lch",
+	SpaceOKLab:     "oklab",
+	SpaceOKLCh:     "oklch",
+}
+
+func (s ColorSpace) String() string {
+	if name, ok := colorSpaceNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Way around the color wheel taken by spaces with a hue
+type HueInterpolation int
+
+const (
+	HueShorter HueInterpolation = iota
+	HueLonger
+)
+
+var hueInterpolationNames = map[HueInterpolation]string{
+	HueShorter: "shorter",
+	HueLonger:  "longer",
+}
+
+func (h HueInterpolation) String() string {
+	if name, ok := hueInterpolationNames[h]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Index of the hue component in the space, -1 if there is no hue
+func (s ColorSpace) getHueIndex() int {
+	switch s {
+	case SpaceHSL, SpaceHSV:
+		return 0
+	case SpaceLCh, SpaceOKLCh:
+		return 2
+	default:
+		return -1
+	}
+}
+
+// Hue of nearly gray colors carries no information and is taken from the other color
+func (s ColorSpace) isHuePowerless(components [3]float64) bool {
+	switch s {
+	case SpaceHSL, SpaceHSV:
+		return components[1] < 1e-6
+	case SpaceLCh:
+		return components[1] < 1e-4
+	case SpaceOKLCh:
+		return components[1] < 1e-6
+	default:
+		return false
+	}
+}
+
+// Interpolates between two colors, t varies from 0 to 1
+// Components other than hue are interpolated alpha-premultiplied, same as in CSS
+func interpolateColors(left, right Color, t float64, space ColorSpace, hue HueInterpolation) Color {
+	if space == SpaceSRGB {
+		leftScale := float32(1 - t)
+		rightScale := float32(t)
+		return Color{
+			R: blend2Vals(left.R, right.R, leftScale, rightScale),
+			G: blend2Vals(left.G, right.G, leftScale, rightScale),
+			B: blend2Vals(left.B, right.B, leftScale, rightScale),
+			A: blend2Vals(left.A, right.A, leftScale, rightScale),
+		}
+	}
+
+	leftStraight := left.toStraight()
+	rightStraight := right.toStraight()
+	leftComponents := space.fromLinearRGB(leftStraight.toLinear())
+	rightComponents := space.fromLinearRGB(rightStraight.toLinear())
+	leftAlpha, rightAlpha := leftStraight.a, rightStraight.a
+	alpha := leftAlpha + (rightAlpha-leftAlpha)*t
+
+	hueIndex := space.getHueIndex()
+	if hueIndex >= 0 {
+		if space.isHuePowerless(leftComponents) {
+			leftComponents[hueIndex] = rightComponents[hueIndex]
+		}
+		if space.isHuePowerless(rightComponents) {
+			rightComponents[hueIndex] = leftComponents[hueIndex]
+		}
+	}
+
+	var components [3]float64
+	for i := range components {
+		if i == hueIndex {
+			components[i] = interpolateHue(leftComponents[i], rightComponents[i], t, hue)
+			continue
+		}
+
+		mixed := leftComponents[i]*leftAlpha + (rightComponents[i]*rightAlpha-leftComponents[i]*leftAlpha)*t
+		if alpha > 0 {
+			components[i] = mixed / alpha
+		}
+	}
+
+	res := space.toLinearRGB(components).toSRGB()
+	res.a = alpha
+	return res.toPremultiplied()
+}
+
+// Hues are in degrees
+func interpolateHue(left, right, t float64, mode HueInterpolation) float64 {
+	diff := right - left
+	switch mode {
+	case HueLonger:
+		if diff > 0 && diff < 180 {
+			diff -= 360
+		} else if diff > -180 && diff <= 0 {
+			diff += 360
+		}
+	default:
+		if diff > 180 {
+			diff -= 360
+		} else if diff < -180 {
+			diff += 360
+		}
+	}
+
+	hue := math.Mod(left+diff*t, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	return hue
+}
+
+// Color with channels from 0 to 1 which are not alpha-premultiplied
+type straightColor struct {
+	r, g, b, a float64
+}
+
+func (c Color) toStraight() straightColor {
+	if c.A == 0 {
+		return straightColor{}
+	}
+
+	a := float64(c.A)
+	return straightColor{
+		r: float64(c.R) / a,
+		g: float64(c.G) / a,
+		b: float64(c.B) / a,
+		a: a / math.MaxUint16,
+	}
+}
+
+func (c straightColor) toPremultiplied() Color {
+	toChannel := func(val float64) uint16 {
+		return uint16(math.Round(math.Max(0, math.Min(1, val)) * math.MaxUint16))
+	}
+
+	a := math.Max(0, math.Min(1, c.a))
+	return Color{
+		R: toChannel(c.r * a),
+		G: toChannel(c.g * a),
+		B: toChannel(c.b * a),
+		A: toChannel(a),
+	}
+}
+
+func srgbToLinear(val float64) float64 {
+	if val <= 0.04045 {
+		return val / 12.92
+	}
+	return math.Pow((val+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(val float64) float64 {
+	if val <= 0.0031308 {
+		return val * 12.92
+	}
+	return 1.055*math.Pow(val, 1/2.4) - 0.055
+}
+
+func (c straightColor) toLinear() straightColor {
+	return straightColor{srgbToLinear(c.r), srgbToLinear(c.g), srgbToLinear(c.b), c.a}
+}
+
+func (c straightColor) toSRGB() straightColor {
+	return straightColor{linearToSRGB(c.r), linearToSRGB(c.g), linearToSRGB(c.b), c.a}
+}
+
+// Alpha is ignored
+func (s ColorSpace) fromLinearRGB(c straightColor) [3]float64 {
+	switch s {
+	case SpaceHSL:
+		return rgbToHSL(c.toSRGB())
+	case SpaceHSV:
+		return rgbToHSV(c.toSRGB())
+	case SpaceLab:
+		return xyzToLab(linearToXYZ(c))
+	case SpaceLCh:
+		return toPolar(xyzToLab(linearToXYZ(c)))
+	case SpaceOKLab:
+		return linearToOKLab(c)
+	case SpaceOKLCh:
+		return toPolar(linearToOKLab(c))
+	default:
+		return [3]float64{c.r, c.g, c.b}
+	}
+}
+
+// Alpha of the result is always 0
+func (s ColorSpace) toLinearRGB(components [3]float64) straightColor {
+	switch s {
+	case SpaceHSL:
+		return hslToRGB(components).toLinear()
+	case SpaceHSV:
+		return hsvToRGB(components).toLinear()
+	case SpaceLab:
+		return xyzToLinear(labToXYZ(components))
+	case SpaceLCh:
+		return xyzToLinear(labToXYZ(fromPolar(components)))
+	case SpaceOKLab:
+		return okLabToLinear(components)
+	case SpaceOKLCh:
+		return okLabToLinear(fromPolar(components))
+	default:
+		return straightColor{r: components[0], g: components[1], b: components[2]}
+	}
+}
+
+// Hue in degrees, saturation and lightness from 0 to 1
+func rgbToHSL(c straightColor) [3]float64 {
+	maxVal := math.Max(c.r, math.Max(c.g, c.b))
+	minVal := math.Min(c.r, math.Min(c.g, c.b))
+	lightness := (maxVal + minVal) / 2
+	delta := maxVal - minVal
+
+	var saturation float64
+	if delta > 0 {
+		saturation = delta / (1 - math.Abs(2*lightness-1))
+	}
+
+	return [3]float64{getHue(c, maxVal, delta), saturation, lightness}
+}
+
+func hslToRGB(hsl [3]float64) straightColor {
+	chroma := (1 - math.Abs(2*hsl[2]-1)) * hsl[1]
+	return hueToRGB(hsl[0], chroma, hsl[2]-chroma/2)
+}
+
+// Hue in degrees, saturation and value from 0 to 1
+func rgbToHSV(c straightColor) [3]float64 {
+	maxVal := math.Max(c.r, math.Max(c.g, c.b))
+	minVal := math.Min(c.r, math.Min(c.g, c.b))
+	delta := maxVal - minVal
+
+	var saturation float64
+	if maxVal > 0 {
+		saturation = delta / maxVal
+	}
+
+	return [3]float64{getHue(c, maxVal, delta), saturation, maxVal}
+}
+
+func hsvToRGB(hsv [3]float64) straightColor {
+	chroma := hsv[2] * hsv[1]
+	return hueToRGB(hsv[0], chroma, hsv[2]-chroma)
+}
+
+func getHue(c straightColor, maxVal, delta float64) float64 {
+	if delta == 0 {
+		return 0
+	}
+
+	var hue float64
+	switch maxVal {
+	case c.r:
+		hue = math.Mod((c.g-c.b)/delta, 6)
+	case c.g:
+		hue = (c.b-c.r)/delta + 2
+	default:
+		hue = (c.r-c.g)/delta + 4
+	}
+
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+	return hue
+}
+
+// Common part of HSL and HSV conversions, lightest is added to every channel
+func hueToRGB(hue, chroma, lightest float64) straightColor {
+	sector := math.Mod(hue, 360) / 60
+	if sector < 0 {
+		sector += 6
+	}
+	x := chroma * (1 - math.Abs(math.Mod(sector, 2)-1))
+
+	var r, g, b float64
+	switch int(sector) {
+	case 0:
+		r, g = chroma, x
+	case 1:
+		r, g = x, chroma
+	case 2:
+		g, b = chroma, x
+	case 3:
+		g, b = x, chroma
+	case 4:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+
+	return straightColor{r: r + lightest, g: g + lightest, b: b + lightest}
+}
+
+// D65 reference white
+const whiteX, whiteY, whiteZ = 0.95047, 1.0, 1.08883
+
+func linearToXYZ(c straightColor) [3]float64 {
+	return [3]float64{
+		0.4124564*c.r + 0.3575761*c.g + 0.1804375*c.b,
+		0.2126729*c.r + 0.7151522*c.g + 0.0721750*c.b,
+		0.0193339*c.r + 0.1191920*c.g + 0.9503041*c.b,
+	}
+}
+
+func xyzToLinear(xyz [3]float64) straightColor {
+	return straightColor{
+		r: 3.2404542*xyz[0] - 1.5371385*xyz[1] - 0.4985314*xyz[2],
+		g: -0.9692660*xyz[0] + 1.8760108*xyz[1] + 0.0415560*xyz[2],
+		b: 0.0556434*xyz[0] - 0.2040259*xyz[1] + 1.0572252*xyz[2],
+	}
+}
+
+func xyzToLab(xyz [3]float64) [3]float64 {
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+
+	fx := f(xyz[0] / whiteX)
+	fy := f(xyz[1] / whiteY)
+	fz := f(xyz[2] / whiteZ)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+func labToXYZ(lab [3]float64) [3]float64 {
+	fy := (lab[0] + 16) / 116
+	fx := fy + lab[1]/500
+	fz := fy - lab[2]/200
+
+	fInv := func(t float64) float64 {
+		if t*t*t > 216.0/24389 {
+			return t * t * t
+		}
+		return (116*t - 16) * 27 / 24389
+	}
+
+	return [3]float64{fInv(fx) * whiteX, fInv(fy) * whiteY, fInv(fz) * whiteZ}
+}
+
+func linearToOKLab(c straightColor) [3]float64 {
+	l := math.Cbrt(0.4122214708*c.r + 0.5363325363*c.g + 0.0514459929*c.b)
+	m := math.Cbrt(0.2119034982*c.r + 0.6806995451*c.g + 0.1073969566*c.b)
+	s := math.Cbrt(0.0883024619*c.r + 0.2817188376*c.g + 0.6299787005*c.b)
+
+	return [3]float64{
+		0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+func okLabToLinear(lab [3]float64) straightColor {
+	l := lab[0] + 0.3963377774*lab[1] + 0.2158037573*lab[2]
+	m := lab[0] - 0.1055613458*lab[1] - 0.0638541728*lab[2]
+	s := lab[0] - 0.0894841775*lab[1] - 1.2914855480*lab[2]
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	return straightColor{
+		r: 4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		g: -1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		b: -0.0041960863*l - 0.7034186147*m + 1.7076147010*s,
+	}
+}
+
+// From lightness, a, b to lightness, chroma, hue in degrees
+func toPolar(lab [3]float64) [3]float64 {
+	hue := math.Atan2(lab[2], lab[1]) * 180 / math.Pi
+	if hue < 0 {
+		hue += 360
+	}
+	return [3]float64{lab[0], math.Hypot(lab[1], lab[2]), hue}
+}
+
+func fromPolar(lch [3]float64) [3]float64 {
+	angle := lch[2] * math.Pi / 180
+	return [3]float64{lch[0], lch[1] * math.Cos(angle), lch[1] * math.Sin(angle)}
+}
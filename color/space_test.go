@@ -0,0 +1,102 @@
+package color_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+)
+
+func getTwoMarkGradient(left, right color.Color, space color.ColorSpace) color.Gradient {
+	grad := color.GradientFromColor(left)
+	grad.SetMark(color.GradientMark{Col: right, Pos: 1})
+	grad.Space = space
+	return grad
+}
+
+func getChannel8(val uint16) int {
+	return int(math.Round(float64(val) / 257))
+}
+
+func TestInterpolationSpacesKeepMarks(t *testing.T) {
+	spaces := []color.ColorSpace{
+		color.SpaceSRGB, color.SpaceLinearRGB, color.SpaceHSL, color.SpaceHSV,
+		color.SpaceLab, color.SpaceLCh, color.SpaceOKLab, color.SpaceOKLCh,
+	}
+	colors := []color.Color{
+		{R: 0xffff, A: 0xffff},
+		{R: 0x1234, G: 0xabcd, B: 0x7777, A: 0xffff},
+		{R: 0x4000, G: 0x2000, B: 0x1000, A: 0x8000},
+		{R: 0x8080, G: 0x8080, B: 0x8080, A: 0xffff},
+	}
+
+	for _, space := range spaces {
+		for _, col := range colors {
+			grad := getTwoMarkGradient(col, color.Color{A: 0xffff}, space)
+			got := grad.GetMarkAt(0.0001).Col
+
+			for i, pair := range [][2]uint16{{got.R, col.R}, {got.G, col.G}, {got.B, col.B}, {got.A, col.A}} {
+				if math.Abs(float64(pair[0])-float64(pair[1])) > 64 {
+					t.Fatalf("Channel %d of %v changes when converted through %v; \nGot: %v", i, col, space, got)
+				}
+			}
+		}
+	}
+}
+
+func TestInterpolationSpaceReferenceValues(t *testing.T) {
+	black := color.Color{A: 0xffff}
+	white := color.Color{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}
+	red := color.Color{R: 0xffff, A: 0xffff}
+	green := color.Color{G: 0xffff, A: 0xffff}
+	blue := color.Color{B: 0xffff, A: 0xffff}
+
+	cases := []struct {
+		left, right color.Color
+		space       color.ColorSpace
+		hue         color.HueInterpolation
+		expected    [3]int
+	}{
+		// Gray with L* of 50 is #777777
+		{black, white, color.SpaceLab, color.HueShorter, [3]int{119, 119, 119}},
+		{black, white, color.SpaceLCh, color.HueShorter, [3]int{119, 119, 119}},
+		// Linear light of 0.5 is 188 in sRGB
+		{black, white, color.SpaceLinearRGB, color.HueShorter, [3]int{188, 188, 188}},
+		// OKLab lightness of 0.5 is linear light of 0.125
+		{black, white, color.SpaceOKLab, color.HueShorter, [3]int{99, 99, 99}},
+		{black, white, color.SpaceSRGB, color.HueShorter, [3]int{127, 127, 127}},
+		{red, green, color.SpaceHSL, color.HueShorter, [3]int{255, 255, 0}},
+		{red, green, color.SpaceHSV, color.HueShorter, [3]int{255, 255, 0}},
+		{red, green, color.SpaceHSL, color.HueLonger, [3]int{0, 0, 255}},
+		{red, blue, color.SpaceHSL, color.HueShorter, [3]int{255, 0, 255}},
+		{red, blue, color.SpaceHSL, color.HueLonger, [3]int{0, 255, 0}},
+	}
+
+	for _, c := range cases {
+		grad := getTwoMarkGradient(c.left, c.right, c.space)
+		grad.Hue = c.hue
+		got := grad.GetMarkAt(0.5).Col
+		gotRGB := [3]int{getChannel8(got.R), getChannel8(got.G), getChannel8(got.B)}
+
+		for i := range gotRGB {
+			if math.Abs(float64(gotRGB[i]-c.expected[i])) > 1 {
+				t.Fatalf("Unexpected middle of %v and %v in %v (%v hue); \nExpected: %v; \nGot: %v", c.left, c.right, c.space, c.hue, c.expected, gotRGB)
+			}
+		}
+	}
+}
+
+func TestGrayKeepsHue(t *testing.T) {
+	white := color.Color{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}
+	blue := color.Color{B: 0xffff, A: 0xffff}
+
+	for _, space := range []color.ColorSpace{color.SpaceHSL, color.SpaceHSV, color.SpaceOKLCh} {
+		grad := getTwoMarkGradient(white, blue, space)
+		got := grad.GetMarkAt(0.5).Col
+
+		// Hue of white is taken from blue, so the middle is a light blue without a red tint
+		if got.B < got.G || got.R > got.G+0x0400 {
+			t.Fatalf("Middle of white and blue in %v should be a light blue; \nGot: %v", space, got)
+		}
+	}
+}
@@ -12,14 +12,23 @@ type Command interface {
 	Execute(*drawing.Drawing) error
 }
 
+// Implemented by commands which give another result when run on parts of their area separately,
+// such commands are run on the whole drawing even by renderers splitting it into tiles
+type WholeAreaCommand interface {
+	Command
+	NeedsWholeArea() bool
+}
+
 // Target is defined in the generator
 type DrawLineCommand struct {
 	Line drawing.Line
-	Grad color.Gradient
+	Grad color.Paint
+	// Overrides the blend mode of the target drawing
+	Blend color.BlendMode
 }
 
 func (command DrawLineCommand) Execute(target *drawing.Drawing) error {
-	drawing.DrawLine(target, command.Line, command.Grad)
+	drawing.DrawLine(target.WithBlend(command.Blend), command.Line, command.Grad)
 	return nil
 }
 
@@ -27,31 +36,121 @@ func (c DrawLineCommand) GetAffectedArea() image.Rectangle {
 	return c.Line.GetAffectedArea()
 }
 
-func FilterRelatedCommands(unfiltered []Command) (filtered, left []Command) {
-	filtered = make([]Command, 0)
-	left = make([]Command, 0)
+// Draws any drawing.Shape, so new primitives do not need a command of their own
+type DrawShapeCommand struct {
+	Shape drawing.Shape
+	Grad  color.Paint
+	Blend color.BlendMode
+}
 
-	var area1, area2 image.Rectangle
-	var isAddable bool
+func (command DrawShapeCommand) Execute(target *drawing.Drawing) error {
+	drawing.DrawShape(target.WithBlend(command.Blend), command.Shape, command.Grad)
+	return nil
+}
 
-	for _, newCom := range unfiltered {
-		isAddable = true
-		for _, filteredCom := range filtered {
-			if !isAddable {
-				break
-			}
+func (c DrawShapeCommand) GetAffectedArea() image.Rectangle {
+	return c.Shape.GetAffectedArea()
+}
 
-			area1 = newCom.GetAffectedArea()
-			area2 = filteredCom.GetAffectedArea()
+type DrawCircleCommand struct {
+	Circle drawing.Circle
+	Grad   color.Paint
+	Blend  color.BlendMode
+}
 
-			isAddable = isAddable && area1.Intersect(area2) == image.Rectangle{}
-		}
+func (command DrawCircleCommand) Execute(target *drawing.Drawing) error {
+	drawing.DrawShape(target.WithBlend(command.Blend), command.Circle, command.Grad)
+	return nil
+}
+
+func (c DrawCircleCommand) GetAffectedArea() image.Rectangle {
+	return c.Circle.GetAffectedArea()
+}
+
+type DrawEllipseCommand struct {
+	Ellipse drawing.Ellipse
+	Grad    color.Paint
+	Blend   color.BlendMode
+}
+
+func (command DrawEllipseCommand) Execute(target *drawing.Drawing) error {
+	drawing.DrawShape(target.WithBlend(command.Blend), command.Ellipse, command.Grad)
+	return nil
+}
+
+func (c DrawEllipseCommand) GetAffectedArea() image.Rectangle {
+	return c.Ellipse.GetAffectedArea()
+}
+
+type DrawPolygonCommand struct {
+	Polygon drawing.Polygon
+	Grad    color.Paint
+	Blend   color.BlendMode
+}
+
+func (command DrawPolygonCommand) Execute(target *drawing.Drawing) error {
+	drawing.DrawShape(target.WithBlend(command.Blend), command.Polygon, command.Grad)
+	return nil
+}
+
+func (c DrawPolygonCommand) GetAffectedArea() image.Rectangle {
+	return c.Polygon.GetAffectedArea()
+}
+
+type DrawPolylineCommand struct {
+	Polyline drawing.Polyline
+	Grad     color.Paint
+	Blend    color.BlendMode
+}
+
+func (command DrawPolylineCommand) Execute(target *drawing.Drawing) error {
+	drawing.DrawShape(target.WithBlend(command.Blend), command.Polyline, command.Grad)
+	return nil
+}
+
+func (c DrawPolylineCommand) GetAffectedArea() image.Rectangle {
+	return c.Polyline.GetAffectedArea()
+}
+
+// Post-process command, reduces the colors of the area with dithering
+// Meant to be the last command affecting its area
+type DitherCommand struct {
+	Area      image.Rectangle
+	Method    drawing.DitherMethod
+	Quantizer color.Quantizer
+}
+
+// Fails with drawing.InvalidDitherMethod if the method is unknown
+func (command DitherCommand) Execute(target *drawing.Drawing) error {
+	return drawing.Dither(target, command.Area, command.Method, command.Quantizer)
+}
+
+func (c DitherCommand) GetAffectedArea() image.Rectangle {
+	return c.Area
+}
+
+// Error diffusion passes the error across the whole area, so it cannot be split into tiles
+// Implements WholeAreaCommand
+func (c DitherCommand) NeedsWholeArea() bool {
+	return c.Method == drawing.DitherFloydSteinberg || c.Method == drawing.DitherAtkinson
+}
+
+// Splits the commands into the ones which can run right away at the same time and the ones left for later
+// A command is left if it overlaps with any earlier command, so the order of overlapping commands is kept
+func FilterRelatedCommands(unfiltered []Command) (filtered, left []Command) {
+	filtered = make([]Command, 0)
+	left = make([]Command, 0)
+
+	index := NewSpatialIndex(DefaultCellSize)
+	for i, newCom := range unfiltered {
+		area := newCom.GetAffectedArea()
 
-		if isAddable {
+		if len(index.Query(area)) == 0 {
 			filtered = append(filtered, newCom)
 		} else {
 			left = append(left, newCom)
 		}
+		index.Insert(i, area)
 	}
 
 	return filtered, left
@@ -0,0 +1,60 @@
+package command
+
+import "image"
+
+// Commands have to run after the earlier commands which affected areas overlap with theirs,
+// commands which do not overlap can run in any order or at the same time
+// Running the commands by the graph gives the same result as running them one by one
+type DependencyGraph struct {
+	// Indexes of the earlier commands every command waits for directly,
+	// a command covered by a later one may be waited for only through the later one
+	Dependencies [][]int
+	// Indexes of the later commands waiting for every command
+	Dependents [][]int
+}
+
+// Overlapping commands are found with a SpatialIndex, so the graph is built in about linear time
+// for commands spread over the image
+// Areas are cut to the bounds of the drawing first, so commands only overlapping outside of it
+// do not wait for each other and commands fully outside of it wait for nothing
+func NewDependencyGraph(commands []Command, bounds image.Rectangle) DependencyGraph {
+	graph := DependencyGraph{
+		Dependencies: make([][]int, len(commands)),
+		Dependents:   make([][]int, len(commands)),
+	}
+
+	index := NewSpatialIndex(DefaultCellSize)
+	for i, comm := range commands {
+		area := comm.GetAffectedArea().Intersect(bounds)
+		for _, dependency := range index.Query(area) {
+			graph.addDependency(i, dependency)
+		}
+		index.InsertOnTop(i, area)
+	}
+
+	return graph
+}
+
+func (g *DependencyGraph) addDependency(command, dependency int) {
+	g.Dependencies[command] = append(g.Dependencies[command], dependency)
+	g.Dependents[dependency] = append(g.Dependents[dependency], command)
+}
+
+// Number of commands in the longest chain of dependent commands,
+// which is the least number of steps the commands can be run in
+func (g DependencyGraph) GetDepth() int {
+	depths := make([]int, len(g.Dependencies))
+	maxDepth := 0
+
+	// Dependencies always come earlier, so their depths are already known
+	for i, dependencies := range g.Dependencies {
+		depth := 0
+		for _, dependency := range dependencies {
+			depth = max(depth, depths[dependency])
+		}
+		depths[i] = depth + 1
+		maxDepth = max(maxDepth, depths[i])
+	}
+
+	return maxDepth
+}
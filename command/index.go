@@ -0,0 +1,109 @@
+package command
+
+import (
+	"image"
+	"slices"
+)
+
+// Side of a grid cell in pixels, lines usually span a few cells
+const DefaultCellSize = 64
+
+// Grid of cells over the plane, every cell keeps the areas touching it
+// Answers which areas overlap a rectangle by looking only at the cells the rectangle touches
+type SpatialIndex struct {
+	cellSize int
+	cells    map[image.Point][]indexEntry
+}
+
+type indexEntry struct {
+	id   int
+	area image.Rectangle
+}
+
+// Cell size less than 1 is replaced with DefaultCellSize
+func NewSpatialIndex(cellSize int) *SpatialIndex {
+	if cellSize < 1 {
+		cellSize = DefaultCellSize
+	}
+
+	return &SpatialIndex{
+		cellSize: cellSize,
+		cells:    make(map[image.Point][]indexEntry),
+	}
+}
+
+// Adds the area, empty areas overlap nothing and are not added
+func (s *SpatialIndex) Insert(id int, area image.Rectangle) {
+	s.insert(id, area, false)
+}
+
+// Same as Insert, but the area is treated as drawn over the earlier ones:
+// cells fully covered by the area forget the earlier areas
+// Query may then miss an earlier area hidden in such a cell, but it always finds the area covering it,
+// which overlaps the earlier one too, so it is enough to find dependencies between commands
+func (s *SpatialIndex) InsertOnTop(id int, area image.Rectangle) {
+	s.insert(id, area, true)
+}
+
+func (s *SpatialIndex) insert(id int, area image.Rectangle, isOnTop bool) {
+	if area.Empty() {
+		return
+	}
+
+	entry := indexEntry{id, area}
+	s.forEachCell(area, func(cell image.Point) {
+		if isOnTop && s.getCellRect(cell).In(area) {
+			s.cells[cell] = append(s.cells[cell][:0], entry)
+			return
+		}
+		s.cells[cell] = append(s.cells[cell], entry)
+	})
+}
+
+// Gives the ids of the areas overlapping with the area, in ascending order
+func (s *SpatialIndex) Query(area image.Rectangle) []int {
+	ids := make([]int, 0)
+	if area.Empty() {
+		return ids
+	}
+
+	s.forEachCell(area, func(cell image.Point) {
+		for _, entry := range s.cells[cell] {
+			if entry.area.Overlaps(area) {
+				ids = append(ids, entry.id)
+			}
+		}
+	})
+
+	// Areas spanning several cells are found more than once
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+func (s *SpatialIndex) forEachCell(area image.Rectangle, action func(cell image.Point)) {
+	minCell := s.getCell(area.Min)
+	maxCell := s.getCell(area.Max.Sub(image.Point{1, 1}))
+
+	for y := minCell.Y; y <= maxCell.Y; y++ {
+		for x := minCell.X; x <= maxCell.X; x++ {
+			action(image.Point{x, y})
+		}
+	}
+}
+
+// Cells are aligned to 0, so negative coordinates are rounded down
+func (s *SpatialIndex) getCell(p image.Point) image.Point {
+	floorDiv := func(val int) int {
+		if val < 0 {
+			return -((-val + s.cellSize - 1) / s.cellSize)
+		}
+		return val / s.cellSize
+	}
+
+	return image.Point{floorDiv(p.X), floorDiv(p.Y)}
+}
+
+func (s *SpatialIndex) getCellRect(cell image.Point) image.Rectangle {
+	origin := cell.Mul(s.cellSize)
+	return image.Rectangle{Min: origin, Max: origin.Add(image.Point{s.cellSize, s.cellSize})}
+}
@@ -0,0 +1,182 @@
+package command_test
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/marattttt/generator/command"
+	"github.com/marattttt/generator/drawing"
+)
+
+// Command which only has an area, used to check scheduling
+type areaCommand struct {
+	area image.Rectangle
+}
+
+func (c areaCommand) Execute(*drawing.Drawing) error {
+	return nil
+}
+
+func (c areaCommand) GetAffectedArea() image.Rectangle {
+	return c.area
+}
+
+func TestSpatialIndexQuery(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	areas := getRandomAreas(random, 2000, 1000, 200)
+	// Areas on negative coordinates and an empty one
+	areas = append(areas, image.Rect(-70, -10, -1, 5), image.Rect(-128, -128, -64, -64), image.Rectangle{})
+
+	index := command.NewSpatialIndex(32)
+	for i, area := range areas {
+		index.Insert(i, area)
+	}
+
+	queries := append(getRandomAreas(random, 200, 1000, 300), image.Rect(-100, -100, 0, 0), image.Rect(-64, -64, -63, -63))
+	for _, query := range queries {
+		expected := make([]int, 0)
+		for i, area := range areas {
+			if area.Overlaps(query) {
+				expected = append(expected, i)
+			}
+		}
+
+		if got := index.Query(query); !slices.Equal(got, expected) {
+			t.Fatalf("Unexpected areas overlapping %v; \nExpected: %v; \nGot: %v", query, expected, got)
+		}
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	commands := make([]command.Command, 0)
+	for _, area := range getRandomAreas(random, 1000, 400, 150) {
+		commands = append(commands, areaCommand{area})
+	}
+	// Large areas cover whole cells, so some dependencies are only kept through them
+	commands = append(commands, areaCommand{image.Rect(0, 0, 400, 400)})
+	for _, area := range getRandomAreas(random, 200, 400, 50) {
+		commands = append(commands, areaCommand{area})
+	}
+
+	graph := command.NewDependencyGraph(commands, image.Rect(0, 0, 1000, 1000))
+
+	// Every command waits for every earlier overlapping command, directly or through others
+	depths := make([]int, len(commands))
+	expectedDepth := 0
+	for i := range commands {
+		waitsFor := getReachable(graph, i)
+		depths[i] = 1
+
+		for j := 0; j < i; j++ {
+			isOverlapping := commands[i].GetAffectedArea().Overlaps(commands[j].GetAffectedArea())
+			if isOverlapping && !waitsFor[j] {
+				t.Fatalf("Command %d does not wait for the earlier overlapping command %d", i, j)
+			}
+			if isOverlapping {
+				depths[i] = max(depths[i], depths[j]+1)
+			}
+		}
+		for _, dependency := range graph.Dependencies[i] {
+			if !commands[i].GetAffectedArea().Overlaps(commands[dependency].GetAffectedArea()) {
+				t.Fatalf("Command %d waits for %d, which does not overlap with it", i, dependency)
+			}
+		}
+		expectedDepth = max(expectedDepth, depths[i])
+	}
+
+	if got := graph.GetDepth(); got != expectedDepth {
+		t.Fatalf("Unexpected depth of the graph; \nExpected: %d; \nGot: %d", expectedDepth, got)
+	}
+}
+
+func TestDependencyGraphClipsToBounds(t *testing.T) {
+	bounds := image.Rect(0, 0, 400, 200)
+	commands := []command.Command{
+		areaCommand{image.Rect(10, 10, 50, 50)},
+		// Covers the drawing, only the part inside of it is indexed
+		areaCommand{image.Rect(-60000, -60000, 60000, 60000)},
+		// Both overlap only with each other and outside of the drawing
+		areaCommand{image.Rect(-100, -100, -10, -10)},
+		areaCommand{image.Rect(-50, -50, -5, -5)},
+		// Overlaps the two previous commands only outside of the drawing
+		areaCommand{image.Rect(-80, -80, 20, 20)},
+	}
+
+	graph := command.NewDependencyGraph(commands, bounds)
+	expected := [][]int{{}, {0}, {}, {}, {1}}
+	for i, dependencies := range graph.Dependencies {
+		if !slices.Equal(dependencies, expected[i]) {
+			t.Fatalf("Unexpected dependencies of command %d; \nExpected: %v; \nGot: %v", i, expected[i], dependencies)
+		}
+	}
+}
+
+func BenchmarkNewDependencyGraph(b *testing.B) {
+	for _, count := range []int{10_000, 100_000} {
+		commands := getRandomLineCommands(count)
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				command.NewDependencyGraph(commands, image.Rect(0, 0, 4096, 4096))
+			}
+		})
+	}
+}
+
+func BenchmarkFilterRelatedCommands(b *testing.B) {
+	for _, count := range []int{10_000, 100_000} {
+		commands := getRandomLineCommands(count)
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				command.FilterRelatedCommands(commands)
+			}
+		})
+	}
+}
+
+// Indexes of the commands the command waits for through any chain of dependencies
+func getReachable(graph command.DependencyGraph, start int) map[int]bool {
+	reachable := make(map[int]bool)
+	stack := []int{start}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, dependency := range graph.Dependencies[current] {
+			if !reachable[dependency] {
+				reachable[dependency] = true
+				stack = append(stack, dependency)
+			}
+		}
+	}
+	return reachable
+}
+
+func getRandomAreas(random *rand.Rand, count, bounds, maxSize int) []image.Rectangle {
+	areas := make([]image.Rectangle, count)
+	for i := range areas {
+		corner := image.Point{random.Intn(bounds), random.Intn(bounds)}
+		areas[i] = image.Rectangle{Min: corner, Max: corner.Add(image.Point{random.Intn(maxSize) + 1, random.Intn(maxSize) + 1})}
+	}
+	return areas
+}
+
+// Short lines over a 4096 x 4096 image
+func getRandomLineCommands(count int) []command.Command {
+	random := rand.New(rand.NewSource(1))
+	commands := make([]command.Command, count)
+	for i := range commands {
+		start := image.Point{random.Intn(4096), random.Intn(4096)}
+		commands[i] = command.DrawLineCommand{
+			Line: drawing.Line{
+				Start:     start,
+				End:       start.Add(image.Point{random.Intn(200) - 100, random.Intn(200) - 100}),
+				Thickness: random.Intn(5) + 1,
+			},
+		}
+	}
+	return commands
+}
@@ -0,0 +1,129 @@
+package drawing
+
+import (
+	"image"
+)
+
+// Curves are split into segments, until every segment is closer than this to the curve
+const bezierFlatness = 0.25
+
+// Limits the number of segments of a single curve to 2^bezierMaxDepth
+const bezierMaxDepth = 16
+
+// Thickness is the width of the stroke, same as for a line
+// The gradient goes along the curve
+// Implements Shape
+type QuadBezier struct {
+	Start, Control, End image.Point
+	Thickness           int
+}
+
+// Thickness is the width of the stroke, same as for a line
+// The gradient goes along the curve
+// Implements Shape
+type CubicBezier struct {
+	Start, Control1, Control2, End image.Point
+	Thickness                      int
+}
+
+func (b QuadBezier) toStroke() strokePath {
+	start := PointFFromPoint(b.Start)
+	points := []PointF{start}
+	points = flattenQuad(points, start, PointFFromPoint(b.Control), PointFFromPoint(b.End), 0)
+
+	return strokePath{
+		points:    points,
+		halfWidth: float64(b.Thickness) / 2,
+		join:      JoinRound,
+	}
+}
+
+func (b QuadBezier) GetAffectedArea() image.Rectangle {
+	// A curve is always inside of the bounds of its control points
+	if b.Thickness <= 0 {
+		return image.Rectangle{}
+	}
+	return getPixelsAround(float64(b.Thickness)/2,
+		PointFFromPoint(b.Start), PointFFromPoint(b.Control), PointFFromPoint(b.End))
+}
+
+func (b QuadBezier) Rasterize(plot PlotFunc) {
+	b.RasterizeIn(b.GetAffectedArea(), plot)
+}
+
+func (b QuadBezier) RasterizeIn(bounds image.Rectangle, plot PlotFunc) {
+	if b.Thickness <= 0 {
+		return
+	}
+	b.toStroke().rasterizeIn(bounds, plot)
+}
+
+func (b CubicBezier) toStroke() strokePath {
+	start := PointFFromPoint(b.Start)
+	points := []PointF{start}
+	points = flattenCubic(points, start, PointFFromPoint(b.Control1), PointFFromPoint(b.Control2), PointFFromPoint(b.End), 0)
+
+	return strokePath{
+		points:    points,
+		halfWidth: float64(b.Thickness) / 2,
+		join:      JoinRound,
+	}
+}
+
+func (b CubicBezier) GetAffectedArea() image.Rectangle {
+	// A curve is always inside of the bounds of its control points
+	if b.Thickness <= 0 {
+		return image.Rectangle{}
+	}
+	return getPixelsAround(float64(b.Thickness)/2,
+		PointFFromPoint(b.Start), PointFFromPoint(b.Control1),
+		PointFFromPoint(b.Control2), PointFFromPoint(b.End))
+}
+
+func (b CubicBezier) Rasterize(plot PlotFunc) {
+	b.RasterizeIn(b.GetAffectedArea(), plot)
+}
+
+func (b CubicBezier) RasterizeIn(bounds image.Rectangle, plot PlotFunc) {
+	if b.Thickness <= 0 {
+		return
+	}
+	b.toStroke().rasterizeIn(bounds, plot)
+}
+
+// Appends the points of the curve after start to dst
+// Splits the curve in halves until the control point is close enough to the chord
+func flattenQuad(dst []PointF, start, control, end PointF, depth int) []PointF {
+	if depth >= bezierMaxDepth || control.distanceToSegment(start, end) <= bezierFlatness {
+		return append(dst, end)
+	}
+
+	// de Casteljau split at t = 0.5
+	left := start.Add(control).Mul(0.5)
+	right := control.Add(end).Mul(0.5)
+	middle := left.Add(right).Mul(0.5)
+
+	dst = flattenQuad(dst, start, left, middle, depth+1)
+	return flattenQuad(dst, middle, right, end, depth+1)
+}
+
+// Appends the points of the curve after start to dst
+// Splits the curve in halves until both control points are close enough to the chord
+func flattenCubic(dst []PointF, start, control1, control2, end PointF, depth int) []PointF {
+	isFlat := control1.distanceToSegment(start, end) <= bezierFlatness &&
+		control2.distanceToSegment(start, end) <= bezierFlatness
+	if depth >= bezierMaxDepth || isFlat {
+		return append(dst, end)
+	}
+
+	// de Casteljau split at t = 0.5
+	p01 := start.Add(control1).Mul(0.5)
+	p12 := control1.Add(control2).Mul(0.5)
+	p23 := control2.Add(end).Mul(0.5)
+	p012 := p01.Add(p12).Mul(0.5)
+	p123 := p12.Add(p23).Mul(0.5)
+	middle := p012.Add(p123).Mul(0.5)
+
+	dst = flattenCubic(dst, start, p01, p012, middle, depth+1)
+	return flattenCubic(dst, middle, p123, p23, end, depth+1)
+}
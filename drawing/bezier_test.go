@@ -0,0 +1,95 @@
+package drawing_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+	"github.com/marattttt/generator/drawing"
+)
+
+func TestDrawStraightQuadBezier(t *testing.T) {
+	white := getWhite()
+	black := getBlack()
+	srcDrawing := getBlackDrawing()
+	bounds := srcDrawing.Img.Bounds()
+	curve := drawing.QuadBezier{
+		Start:     image.Point{10, 100},
+		Control:   image.Point{200, 100},
+		End:       image.Point{390, 100},
+		Thickness: 3,
+	}
+
+	col := color.ColorFromStdColor(white)
+	drawing.DrawShape(&srcDrawing, curve, color.GradientFromColor(col))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			col := srcDrawing.Img.At(x, y)
+			if y >= 99 && y <= 101 && x >= 10 && x <= 390 {
+				if col != white {
+					t.Fatalf("[%d;%d] should be on the curve", x, y)
+				}
+			} else if col != black {
+				t.Fatalf("[%d;%d] should not change", x, y)
+			}
+		}
+	}
+}
+
+func TestBezierGradientFollowsArcLength(t *testing.T) {
+	// Symmetric curve, so the middle of it is at the top
+	curve := drawing.CubicBezier{
+		Start:     image.Point{20, 180},
+		Control1:  image.Point{20, 0},
+		Control2:  image.Point{380, 0},
+		End:       image.Point{380, 180},
+		Thickness: 1,
+	}
+
+	area := curve.GetAffectedArea()
+	topY := math.MaxInt
+	var topProgress float32
+	curve.Rasterize(func(x, y int, coverage, progress float32) {
+		if !(image.Point{x, y}).In(area) {
+			t.Fatalf("[%d;%d] is drawn outside of the affected area %v", x, y, area)
+		}
+		if x == 200 && y < topY {
+			topY = y
+			topProgress = progress
+		}
+		if y == 180 && x < 100 && progress > 0.01 {
+			t.Fatalf("Progress at the start of a curve should be 0, got %v", progress)
+		}
+		if y == 180 && x > 300 && progress < 0.99 {
+			t.Fatalf("Progress at the end of a curve should be 1, got %v", progress)
+		}
+	})
+
+	if math.Abs(float64(topProgress)-0.5) > 0.01 {
+		t.Fatalf("Progress in the middle of a symmetric curve should be 0.5, got %v", topProgress)
+	}
+}
+
+func TestQuadBezierOvershootingEnd(t *testing.T) {
+	// The curve goes past its end and turns back, the furthest point is at x = 66.67 for t = 2/3
+	curve := drawing.QuadBezier{
+		Start:     image.Point{0, 10},
+		Control:   image.Point{100, 10},
+		End:       image.Point{50, 10},
+		Thickness: 1,
+	}
+
+	maxX := math.MinInt
+	curve.Rasterize(func(x, y int, coverage, progress float32) {
+		if y != 10 {
+			t.Fatalf("[%d;%d] is off the curve", x, y)
+		}
+		maxX = max(maxX, x)
+	})
+
+	if maxX < 66 || maxX > 67 {
+		t.Fatalf("Unexpected furthest pixel of the curve; \nExpected: %v; \nGot: %v", 66, maxX)
+	}
+}
@@ -0,0 +1,42 @@
+package drawing
+
+import (
+	"image"
+	std_color "image/color"
+	"image/draw"
+)
+
+// Image limited to a part of another image, pixels outside of the clip are neither read nor changed
+// Lets several workers draw on one image at the same time, each in its own part
+type clippedImage struct {
+	draw.Image
+	clip image.Rectangle
+}
+
+func (c clippedImage) Bounds() image.Rectangle {
+	return c.clip
+}
+
+func (c clippedImage) At(x, y int) std_color.Color {
+	if !(image.Point{x, y}).In(c.clip) {
+		return std_color.RGBA64{}
+	}
+	return c.Image.At(x, y)
+}
+
+func (c clippedImage) Set(x, y int, col std_color.Color) {
+	if (image.Point{x, y}).In(c.clip) {
+		c.Image.Set(x, y, col)
+	}
+}
+
+// Gives a copy of the drawing which only draws inside of the rectangle, the image is shared with the original
+// Shapes and gradients are positioned the same as on the original drawing
+func (d *Drawing) ClipTo(rect image.Rectangle) *Drawing {
+	clipped := *d
+	clipped.Img = clippedImage{
+		Image: d.Img,
+		clip:  rect.Intersect(d.Img.Bounds()),
+	}
+	return &clipped
+}
@@ -0,0 +1,122 @@
+package drawing
+
+import (
+	"math"
+)
+
+// Lengths of dashes and gaps between them, starting with a dash and repeated along a stroke
+// An odd number of lengths is repeated twice, so dashes and gaps alternate
+// Offset moves the pattern backwards along the stroke
+// An empty pattern or a pattern with negative or only zero lengths draws a solid stroke
+type DashPattern struct {
+	Lengths []float64
+	Offset  float64
+}
+
+func (p DashPattern) isDashed() bool {
+	total := 0.0
+	for _, length := range p.Lengths {
+		if length < 0 {
+			return false
+		}
+		total += length
+	}
+
+	return total > 0
+}
+
+func (p DashPattern) getLengths() []float64 {
+	if len(p.Lengths)%2 == 1 {
+		return append(append([]float64{}, p.Lengths...), p.Lengths...)
+	}
+	return p.Lengths
+}
+
+// Gives the index of the part of the pattern at the given distance along a stroke
+// and the distance left until the end of that part
+func (p DashPattern) locate(distance float64) (index int, left float64) {
+	lengths := p.getLengths()
+	total := 0.0
+	for _, length := range lengths {
+		total += length
+	}
+
+	pos := math.Mod(distance+p.Offset, total)
+	if pos < 0 {
+		pos += total
+	}
+
+	for i, length := range lengths {
+		if pos < length {
+			return i, length - pos
+		}
+		pos -= length
+	}
+
+	return 0, lengths[0]
+}
+
+// Reports if the distance along a stroke is inside of a dash
+// Expects the pattern to be dashed
+func (p DashPattern) isOn(distance float64) bool {
+	index, _ := p.locate(distance)
+	return index%2 == 0
+}
+
+// Splits the stroke into dashes, distances along the stroke are kept for the gradient
+func (s strokePath) getDashes(pattern DashPattern) []strokePath {
+	if !pattern.isDashed() {
+		return []strokePath{s}
+	}
+
+	lengths := pattern.getLengths()
+	pathLengths := getPathLengths(s.points)
+	totalLength := pathLengths[len(pathLengths)-1]
+
+	dashes := make([]strokePath, 0)
+	index, left := pattern.locate(0)
+	distance := 0.0
+
+	for distance < totalLength {
+		end := math.Min(distance+left, totalLength)
+		if index%2 == 0 {
+			dash := s
+			dash.points = getSubPath(s.points, pathLengths, distance, end)
+			dash.lengthOffset = s.lengthOffset + distance
+			dash.totalLength = s.getTotalLength()
+			dashes = append(dashes, dash)
+		}
+
+		distance += left
+		index = (index + 1) % len(lengths)
+		left = lengths[index]
+	}
+
+	return dashes
+}
+
+// Points of the part of the path between two distances along it
+func getSubPath(points []PointF, pathLengths []float64, from, to float64) []PointF {
+	pointAt := func(distance float64) PointF {
+		for i := 1; i < len(points); i++ {
+			if pathLengths[i] >= distance {
+				segmentLength := pathLengths[i] - pathLengths[i-1]
+				if segmentLength == 0 {
+					return points[i]
+				}
+				t := (distance - pathLengths[i-1]) / segmentLength
+				return points[i-1].Add(points[i].Sub(points[i-1]).Mul(t))
+			}
+		}
+		return points[len(points)-1]
+	}
+
+	subPath := []PointF{pointAt(from)}
+	for i, length := range pathLengths {
+		if length > from && length < to {
+			subPath = append(subPath, points[i])
+		}
+	}
+
+	return append(subPath, pointAt(to))
+}
@@ -0,0 +1,82 @@
+package drawing_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/marattttt/generator/drawing"
+)
+
+func TestDashedLine(t *testing.T) {
+	solid := drawing.Line{
+		Start:     image.Point{0, 50},
+		End:       image.Point{100, 50},
+		Thickness: 1,
+	}
+	dashed := solid
+	dashed.Dash = drawing.DashPattern{Lengths: []float64{10, 5}}
+
+	solidPixels := getCoveredPixels(t, solid)
+	dashedPixels := getCoveredPixels(t, dashed)
+
+	for x := 0; x <= 100; x++ {
+		p := image.Point{x, 50}
+		progress, ok := dashedPixels[p]
+		isOn := x%15 < 10
+
+		if isOn != ok {
+			t.Fatalf("[%d;%d] unexpected dash state; \nExpected: %v; \nGot: %v", p.X, p.Y, isOn, ok)
+		}
+		if ok && progress != solidPixels[p] {
+			t.Fatalf("[%d;%d] dashes should not change the gradient; \nExpected: %v; \nGot: %v", p.X, p.Y, solidPixels[p], progress)
+		}
+	}
+}
+
+func TestDashOffset(t *testing.T) {
+	line := drawing.Line{
+		Start:     image.Point{0, 50},
+		End:       image.Point{100, 50},
+		Thickness: 1,
+		Dash:      drawing.DashPattern{Lengths: []float64{10}, Offset: 5},
+	}
+
+	pixels := getCoveredPixels(t, line)
+	for x := 0; x <= 100; x++ {
+		_, ok := pixels[image.Point{x, 50}]
+		isOn := (x+5)%20 < 10
+		if isOn != ok {
+			t.Fatalf("[%d;50] unexpected dash state; \nExpected: %v; \nGot: %v", x, isOn, ok)
+		}
+	}
+}
+
+func TestDashedPolylineContinuesAcrossSegments(t *testing.T) {
+	polyline := drawing.Polyline{
+		Points:    []image.Point{{0, 0}, {12, 0}, {12, 100}},
+		Thickness: 1,
+		Dash:      drawing.DashPattern{Lengths: []float64{5, 5}},
+	}
+
+	pixels := getCoveredPixels(t, polyline)
+
+	// The dash from 10 to 15 goes around the corner
+	covered := []image.Point{{2, 0}, {11, 0}, {12, 1}, {12, 10}}
+	excluded := []image.Point{{7, 0}, {12, 7}}
+
+	for _, p := range covered {
+		if _, ok := pixels[p]; !ok {
+			t.Fatalf("[%d;%d] should be inside of a dash", p.X, p.Y)
+		}
+	}
+	for _, p := range excluded {
+		if _, ok := pixels[p]; ok {
+			t.Fatalf("[%d;%d] should be in a gap between dashes", p.X, p.Y)
+		}
+	}
+
+	// Distance of [12;10] is 22 out of 112
+	if progress := pixels[image.Point{12, 10}]; progress < 0.19 || progress > 0.2 {
+		t.Fatalf("Dashes should sample the gradient along the whole path, got %v", progress)
+	}
+}
@@ -0,0 +1,328 @@
+package drawing
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/marattttt/generator/color"
+)
+
+type DitherMethod int
+
+const (
+	// Ordered dithering with Bayer threshold matrices of the size
+	DitherBayer2 DitherMethod = iota
+	DitherBayer4
+	DitherBayer8
+
+	// Error diffusion, the error of every pixel is passed to the pixels not processed yet
+
+	// Spreads the whole error to 4 neighbours
+	DitherFloydSteinberg
+	// Spreads 3/4 of the error to 6 neighbours, which keeps more contrast
+	DitherAtkinson
+
+	// Ordered dithering with a blue noise threshold texture, which has no visible pattern
+	DitherBlueNoise
+)
+
+var ditherMethodNames = map[DitherMethod]string{
+	DitherBayer2:         "bayer-2",
+	DitherBayer4:         "bayer-4",
+	DitherBayer8:         "bayer-8",
+	DitherFloydSteinberg: "floyd-steinberg",
+	DitherAtkinson:       "atkinson",
+	DitherBlueNoise:      "blue-noise",
+}
+
+type InvalidDitherMethod struct {
+	Method DitherMethod
+}
+
+func (invalidMethod InvalidDitherMethod) Error() string {
+	return fmt.Sprintf("Invalid dither method %d", int(invalidMethod.Method))
+}
+
+func (m DitherMethod) String() string {
+	if name, ok := ditherMethodNames[m]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Reduces the colors of the area to the ones given by the quantizer, e.g. color.Palette or color.Levels,
+// spreading the error, so gradients do not band
+// Threshold patterns are aligned to the image, so dithering neighbouring areas separately leaves no seams
+// Returns InvalidDitherMethod for an unknown method, the drawing is then left unchanged
+func Dither(d *Drawing, area image.Rectangle, method DitherMethod, quantizer color.Quantizer) error {
+	if _, ok := ditherMethodNames[method]; !ok {
+		return InvalidDitherMethod{method}
+	}
+
+	area = area.Intersect(d.Img.Bounds())
+	if area.Empty() {
+		return nil
+	}
+
+	switch method {
+	case DitherFloydSteinberg:
+		d.ditherDiffusion(area, quantizer, floydSteinberg)
+	case DitherAtkinson:
+		d.ditherDiffusion(area, quantizer, atkinson)
+	case DitherBlueNoise:
+		d.ditherOrdered(area, quantizer, getBlueNoise())
+	case DitherBayer2:
+		d.ditherOrdered(area, quantizer, getBayerMatrix(2))
+	case DitherBayer4:
+		d.ditherOrdered(area, quantizer, getBayerMatrix(4))
+	case DitherBayer8:
+		d.ditherOrdered(area, quantizer, getBayerMatrix(8))
+	}
+	return nil
+}
+
+// Square matrix of thresholds, every value from 0 to size*size-1 is used once
+type thresholdMatrix struct {
+	size   int
+	values []int
+}
+
+// Gives the threshold of the pixel from -0.5 to 0.5
+func (m thresholdMatrix) getThreshold(x, y int) float64 {
+	x = ((x % m.size) + m.size) % m.size
+	y = ((y % m.size) + m.size) % m.size
+	count := float64(m.size * m.size)
+	return (float64(m.values[y*m.size+x])+0.5)/count - 0.5
+}
+
+func (d *Drawing) ditherOrdered(area image.Rectangle, quantizer color.Quantizer, matrix thresholdMatrix) {
+	spread := getSpread(quantizer)
+
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			col := toDitherColor(color.ColorFromStdColor(d.Img.At(x, y)))
+			offset := matrix.getThreshold(x, y) * spread
+			for i := 0; i < 3; i++ {
+				col[i] += offset
+			}
+
+			d.Img.Set(x, y, quantizer.Nearest(col.toColor()))
+		}
+	}
+}
+
+// Expected distance between the closest colors of the quantizer, in channel values
+func getSpread(quantizer color.Quantizer) float64 {
+	switch q := quantizer.(type) {
+	case color.Levels:
+		if q < 2 {
+			return 0
+		}
+		return math.MaxUint16 / float64(q-1)
+	case color.Palette:
+		perChannel := math.Cbrt(float64(len(q)))
+		return math.MaxUint16 / math.Max(perChannel-1, 1)
+	default:
+		// One step of an 8 bit channel
+		return math.MaxUint16 / math.MaxUint8
+	}
+}
+
+// Premultiplied channels, which may go out of range while the error is spread
+type ditherColor [4]float64
+
+func toDitherColor(c color.Color) ditherColor {
+	return ditherColor{float64(c.R), float64(c.G), float64(c.B), float64(c.A)}
+}
+
+// Clamps the channels, so the result is a valid premultiplied color
+func (c ditherColor) toColor() color.Color {
+	toChannel := func(val, maxVal float64) uint16 {
+		return uint16(math.Round(math.Max(0, math.Min(maxVal, val))))
+	}
+
+	a := toChannel(c[3], math.MaxUint16)
+	return color.Color{
+		R: toChannel(c[0], float64(a)),
+		G: toChannel(c[1], float64(a)),
+		B: toChannel(c[2], float64(a)),
+		A: a,
+	}
+}
+
+// Part of the error passed to the pixel at the offset
+type diffusion struct {
+	dx, dy int
+	weight float64
+}
+
+var floydSteinberg = []diffusion{
+	{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16},
+}
+
+var atkinson = []diffusion{
+	{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8}, {0, 2, 1.0 / 8},
+}
+
+// The error is spread only inside of the area, pixels are processed row by row
+func (d *Drawing) ditherDiffusion(area image.Rectangle, quantizer color.Quantizer, diffusions []diffusion) {
+	width := area.Dx()
+	colors := make([]ditherColor, width*area.Dy())
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			colors[(y-area.Min.Y)*width+x-area.Min.X] = toDitherColor(color.ColorFromStdColor(d.Img.At(x, y)))
+		}
+	}
+
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			col := colors[(y-area.Min.Y)*width+x-area.Min.X]
+			quantized := quantizer.Nearest(col.toColor())
+			d.Img.Set(x, y, quantized)
+
+			diff := toDitherColor(quantized)
+			for c := range diff {
+				diff[c] = col[c] - diff[c]
+			}
+			for _, diffusion := range diffusions {
+				p := image.Point{x + diffusion.dx, y + diffusion.dy}
+				if !p.In(area) {
+					continue
+				}
+
+				i := (p.Y-area.Min.Y)*width + p.X - area.Min.X
+				for c := range diff {
+					colors[i][c] += diff[c] * diffusion.weight
+				}
+			}
+		}
+	}
+}
+
+// Bayer matrix of a power of 2 size, built from the 2x2 one
+func getBayerMatrix(size int) thresholdMatrix {
+	matrix := thresholdMatrix{size: 1, values: []int{0}}
+	for matrix.size < size {
+		prev := matrix
+		matrix = thresholdMatrix{size: prev.size * 2, values: make([]int, prev.size*prev.size*4)}
+
+		// Quadrants get 4*M, 4*M+2, 4*M+3 and 4*M+1
+		quadrants := []struct{ dx, dy, add int }{{0, 0, 0}, {1, 0, 2}, {0, 1, 3}, {1, 1, 1}}
+		for _, quadrant := range quadrants {
+			for y := 0; y < prev.size; y++ {
+				for x := 0; x < prev.size; x++ {
+					val := prev.values[y*prev.size+x]*4 + quadrant.add
+					matrix.values[(y+quadrant.dy*prev.size)*matrix.size+x+quadrant.dx*prev.size] = val
+				}
+			}
+		}
+	}
+	return matrix
+}
+
+const blueNoiseSize = 64
+
+var blueNoise thresholdMatrix
+var blueNoiseOnce sync.Once
+
+// Generated once with the void-and-cluster method, a fixed seed keeps it the same for every run
+func getBlueNoise() thresholdMatrix {
+	blueNoiseOnce.Do(func() {
+		blueNoise = generateBlueNoise(blueNoiseSize, 1.5, rand.New(rand.NewSource(1)))
+	})
+	return blueNoise
+}
+
+// Pixels are ranked by the order they fill the largest voids in,
+// a void is where the gaussian energy of the already set pixels is the lowest
+func generateBlueNoise(size int, sigma float64, random *rand.Rand) thresholdMatrix {
+	count := size * size
+
+	// Energy given by a set pixel to every offset, wrapping around the edges
+	kernel := make([]float64, count)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx := float64(min(x, size-x))
+			dy := float64(min(y, size-y))
+			kernel[y*size+x] = math.Exp(-(dx*dx + dy*dy) / (2 * sigma * sigma))
+		}
+	}
+
+	isSet := make([]bool, count)
+	energy := make([]float64, count)
+	update := func(i int, set bool) {
+		isSet[i] = set
+		sign := 1.0
+		if !set {
+			sign = -1
+		}
+
+		px, py := i%size, i/size
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				offset := ((y-py+size)%size)*size + (x-px+size)%size
+				energy[y*size+x] += sign * kernel[offset]
+			}
+		}
+	}
+	// Tightest cluster is the set pixel with the highest energy, largest void is the free one with the lowest
+	find := func(set bool, isBetter func(e1, e2 float64) bool) int {
+		best := -1
+		for i := range energy {
+			if isSet[i] == set && (best < 0 || isBetter(energy[i], energy[best])) {
+				best = i
+			}
+		}
+		return best
+	}
+	findCluster := func() int {
+		return find(true, func(e1, e2 float64) bool { return e1 > e2 })
+	}
+	findVoid := func() int {
+		return find(false, func(e1, e2 float64) bool { return e1 < e2 })
+	}
+
+	// Random initial pattern is relaxed by moving pixels from clusters to voids
+	initialCount := count / 10
+	for _, i := range random.Perm(count)[:initialCount] {
+		update(i, true)
+	}
+	// Usually settles in a few hundred moves, the limit only guards against cycles
+	for moves := 0; moves < count; moves++ {
+		cluster := findCluster()
+		update(cluster, false)
+		void := findVoid()
+		if void == cluster {
+			update(cluster, true)
+			break
+		}
+		update(void, true)
+	}
+	initial := make([]bool, count)
+	copy(initial, isSet)
+	initialEnergy := make([]float64, count)
+	copy(initialEnergy, energy)
+
+	values := make([]int, count)
+
+	// Pixels of the initial pattern are ranked by removing the tightest clusters
+	for rank := initialCount - 1; rank >= 0; rank-- {
+		cluster := findCluster()
+		update(cluster, false)
+		values[cluster] = rank
+	}
+
+	// Other pixels are ranked by filling the largest voids
+	copy(isSet, initial)
+	copy(energy, initialEnergy)
+	for rank := initialCount; rank < count; rank++ {
+		void := findVoid()
+		update(void, true)
+		values[void] = rank
+	}
+
+	return thresholdMatrix{size: size, values: values}
+}
@@ -0,0 +1,133 @@
+package drawing_test
+
+import (
+	"image"
+	std_color "image/color"
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+	"github.com/marattttt/generator/drawing"
+)
+
+var ditherMethods = []drawing.DitherMethod{
+	drawing.DitherBayer2,
+	drawing.DitherBayer4,
+	drawing.DitherBayer8,
+	drawing.DitherFloydSteinberg,
+	drawing.DitherAtkinson,
+	drawing.DitherBlueNoise,
+}
+
+func TestDitherKeepsBrightness(t *testing.T) {
+	palette := color.Palette{color.MustParse("#000"), color.MustParse("#fff")}
+
+	for _, method := range ditherMethods {
+		for _, gray := range []uint16{0x4000, 0x8000, 0xc000} {
+			d := getGrayDrawing(gray)
+			drawing.Dither(&d, d.Img.Bounds(), method, palette)
+
+			whites := 0
+			bounds := d.Img.Bounds()
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					switch d.Img.At(x, y) {
+					case std_color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}:
+						whites++
+					case std_color.RGBA64{0, 0, 0, 0xffff}:
+					default:
+						t.Fatalf("%v gave color %v at [%d;%d], which is not in the palette", method, d.Img.At(x, y), x, y)
+					}
+				}
+			}
+
+			// Atkinson drops a quarter of the error, so it is less precise
+			expected := float64(gray) / math.MaxUint16
+			got := float64(whites) / float64(bounds.Dx()*bounds.Dy())
+			if math.Abs(got-expected) > 0.1 {
+				t.Fatalf("%v of gray %#x gave %.2f white pixels, expected about %.2f", method, gray, got, expected)
+			}
+		}
+	}
+}
+
+func TestDitherToLevels(t *testing.T) {
+	// Gray between two 8 bit values, so plain rounding would give a flat color
+	gray := uint16(0x8080 + 0x80)
+
+	for _, method := range ditherMethods {
+		d := getGrayDrawing(gray)
+		drawing.Dither(&d, d.Img.Bounds(), method, color.Levels(256))
+
+		var sum float64
+		bounds := d.Img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r, _, _, _ := d.Img.At(x, y).RGBA()
+				if r%0x101 != 0 {
+					t.Fatalf("%v gave %#x at [%d;%d], which is not an 8 bit value", method, r, x, y)
+				}
+				sum += float64(r)
+			}
+		}
+
+		mean := sum / float64(bounds.Dx()*bounds.Dy())
+		if math.Abs(mean-float64(gray)) > 0x40 {
+			t.Fatalf("%v changed the mean of gray %#x to %#x", method, gray, int(mean))
+		}
+	}
+}
+
+func TestDitherOnlyChangesArea(t *testing.T) {
+	palette := color.Palette{color.MustParse("#000"), color.MustParse("#fff")}
+	area := image.Rect(10, 10, 30, 30)
+
+	for _, method := range ditherMethods {
+		d := getGrayDrawing(0x8000)
+		drawing.Dither(&d, area, method, palette)
+
+		bounds := d.Img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				isGray := d.Img.At(x, y) == std_color.RGBA64{0x8000, 0x8000, 0x8000, 0xffff}
+				if isGray == (image.Point{x, y}).In(area) {
+					t.Fatalf("%v changed [%d;%d] outside of the area or left it unchanged inside", method, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestDitherIsDeterministic(t *testing.T) {
+	palette := color.Palette{color.MustParse("#000"), color.MustParse("#f00"), color.MustParse("#fff")}
+
+	for _, method := range ditherMethods {
+		d1 := getGrayDrawing(0x6000)
+		d2 := getGrayDrawing(0x6000)
+		drawing.Dither(&d1, d1.Img.Bounds(), method, palette)
+		drawing.Dither(&d2, d2.Img.Bounds(), method, palette)
+
+		assertSameDrawings(t, d1, d2)
+	}
+}
+
+func TestDitherUnknownMethod(t *testing.T) {
+	d := getGrayDrawing(0x6000)
+	err := drawing.Dither(&d, d.Img.Bounds(), drawing.DitherMethod(100), color.Levels(2))
+	if _, ok := err.(drawing.InvalidDitherMethod); !ok {
+		t.Fatalf("Unexpected error for an unknown method; \nExpected: InvalidDitherMethod; \nGot: %v", err)
+	}
+
+	assertSameDrawings(t, getGrayDrawing(0x6000), d)
+}
+
+func getGrayDrawing(gray uint16) drawing.Drawing {
+	img := image.NewRGBA64(image.Rect(0, 0, 64, 64))
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.Set(x, y, std_color.RGBA64{gray, gray, gray, 0xffff})
+		}
+	}
+	return drawing.Drawing{Img: img}
+}
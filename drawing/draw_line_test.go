@@ -4,6 +4,7 @@ import (
 	"image"
 	std_color "image/color"
 	"image/draw"
+	"math"
 	"testing"
 
 	"github.com/marattttt/generator/color"
@@ -179,6 +180,229 @@ func TestDrawLineZeroThickness(t *testing.T) {
 	}
 }
 
+func TestDrawLineAntiAliased(t *testing.T) {
+	white := getWhite()
+	black := getBlack()
+	line := drawing.Line{
+		Start:     image.Point{0, 0},
+		End:       image.Point{100, 50},
+		Thickness: 1,
+		AntiAlias: true,
+	}
+
+	// Every column of a line with thickness 1 should be covered exactly once
+	coverages := make(map[int]float32)
+	line.Rasterize(func(x, y int, coverage, progress float32) {
+		coverages[x] += coverage
+	})
+	for x := 0; x <= 100; x++ {
+		if math.Abs(float64(coverages[x])-1) > 0.001 {
+			t.Fatalf("Column %d should have a total coverage of 1, got %v", x, coverages[x])
+		}
+	}
+
+	// Drawing option should work the same as the line option, with both DrawLine and DrawShape
+	byLine := getBlackDrawing()
+	byDrawing := getBlackDrawing()
+	byDrawing.AntiAlias = true
+	byShape := getBlackDrawing()
+	byShape.AntiAlias = true
+	col := color.GradientFromColor(color.ColorFromStdColor(white))
+
+	drawing.DrawLine(&byLine, line, col)
+	line.AntiAlias = false
+	drawing.DrawLine(&byDrawing, line, col)
+	drawing.DrawShape(&byShape, line, col)
+
+	assertSameDrawings(t, byLine, byDrawing)
+	assertSameDrawings(t, byLine, byShape)
+
+	// The line goes through the middle of [1;0] and [1;1], so both are half covered
+	for _, p := range []image.Point{{1, 0}, {1, 1}} {
+		got := byLine.Img.At(p.X, p.Y)
+		if got == white || got == black {
+			t.Fatalf("[%d;%d] should be partially covered, got %v", p.X, p.Y, got)
+		}
+	}
+}
+
+func TestDrawLineBlendMode(t *testing.T) {
+	red := color.Color{R: math.MaxUint16, A: math.MaxUint16}
+	green := color.Color{G: math.MaxUint16, A: math.MaxUint16}
+	yellow := std_color.RGBA{255, 255, 0, 255}
+
+	lines := []drawing.Line{
+		{Start: image.Point{10, 100}, End: image.Point{300, 100}, Thickness: 1},
+		{Start: image.Point{200, 10}, End: image.Point{200, 150}, Thickness: 1},
+		{Start: image.Point{10, 10}, End: image.Point{150, 150}, Thickness: 1},
+	}
+
+	for _, line := range lines {
+		srcDrawing := getBlackDrawing()
+		draw.Draw(srcDrawing.Img, srcDrawing.Img.Bounds(), &image.Uniform{green}, image.Point{}, draw.Src)
+		srcDrawing.Blend = color.BlendAdditive
+
+		drawing.DrawLine(&srcDrawing, line, color.GradientFromColor(red))
+
+		got := srcDrawing.Img.At(line.Start.X, line.Start.Y)
+		if got != yellow {
+			t.Fatalf("Line %v is not drawn with the blend mode of the drawing; \nExpected: %v; \nGot: %v", line, yellow, got)
+		}
+	}
+}
+
+func TestDrawLineBlendModeAntiAliased(t *testing.T) {
+	red := color.Color{R: math.MaxUint16, A: math.MaxUint16}
+	green := color.Color{G: math.MaxUint16, A: math.MaxUint16}
+	line := drawing.Line{
+		Start:     image.Point{10, 10},
+		End:       image.Point{150, 80},
+		Thickness: 2,
+		AntiAlias: true,
+	}
+
+	coverages := make(map[image.Point]float32)
+	line.Rasterize(func(x, y int, coverage, progress float32) {
+		coverages[image.Point{x, y}] = coverage
+	})
+
+	// Uncovered part of a pixel keeps the green background, even if the mode replaces or clears it
+	modes := []struct {
+		mode     color.BlendMode
+		expected func(coverage float64) std_color.RGBA
+	}{
+		{color.BlendSrc, func(coverage float64) std_color.RGBA {
+			return std_color.RGBA{uint8(math.Round(255 * coverage)), uint8(math.Round(255 * (1 - coverage))), 0, 255}
+		}},
+		{color.BlendClear, func(coverage float64) std_color.RGBA {
+			return std_color.RGBA{0, uint8(math.Round(255 * (1 - coverage))), 0, uint8(math.Round(255 * (1 - coverage)))}
+		}},
+	}
+
+	for _, m := range modes {
+		srcDrawing := getBlackDrawing()
+		draw.Draw(srcDrawing.Img, srcDrawing.Img.Bounds(), &image.Uniform{green}, image.Point{}, draw.Src)
+		srcDrawing.Blend = m.mode
+
+		drawing.DrawLine(&srcDrawing, line, color.GradientFromColor(red))
+
+		for p, coverage := range coverages {
+			expected := m.expected(float64(coverage))
+			got := std_color.RGBAModel.Convert(srcDrawing.Img.At(p.X, p.Y)).(std_color.RGBA)
+			if !isCloseRGBA(got, expected) {
+				t.Fatalf("Unexpected color of [%d;%d] with %v and coverage %v; \nExpected: %v; \nGot: %v", p.X, p.Y, m.mode, coverage, expected, got)
+			}
+		}
+	}
+}
+
+func TestDrawLineLinearLight(t *testing.T) {
+	line := drawing.Line{
+		Start:     image.Point{0, 0},
+		End:       image.Point{100, 50},
+		Thickness: 1,
+		AntiAlias: true,
+	}
+	white := color.GradientFromColor(color.ColorFromStdColor(getWhite()))
+
+	srgb := getBlackDrawing()
+	linear := getBlackDrawing()
+	linear.LinearLight = true
+
+	drawing.DrawLine(&srgb, line, white)
+	drawing.DrawLine(&linear, line, white)
+
+	// [1;0] is half covered, so it gets half of the light in linear light
+	expected := map[*drawing.Drawing]uint8{
+		&srgb:   128,
+		&linear: 188,
+	}
+	for d, val := range expected {
+		got := std_color.RGBAModel.Convert(d.Img.At(1, 0)).(std_color.RGBA)
+		// The coverage is rounded, so the channels may be off by 1
+		if math.Abs(float64(got.R)-float64(val)) > 1 || got.R != got.G || got.G != got.B {
+			t.Fatalf("Unexpected color of a half covered pixel with linear light set to %v; \nExpected: %v; \nGot: %v",
+				d.LinearLight, val, got)
+		}
+	}
+
+	// Fully covered pixels are the same
+	if got := linear.Img.At(0, 0); got != getWhite() {
+		t.Fatalf("Fully covered pixel should be white, got %v", got)
+	}
+}
+
+func TestDrawLineOnSubImage(t *testing.T) {
+	grad := color.GradientFromColor(color.ColorFromStdColor(getBlack()))
+	grad.SetMark(color.GradientMark{Col: color.ColorFromStdColor(getWhite()), Pos: 1})
+
+	lines := []drawing.Line{
+		{Start: image.Point{0, 100}, End: image.Point{399, 100}, Thickness: 3},
+		{Start: image.Point{200, 0}, End: image.Point{200, 199}, Thickness: 2},
+		{Start: image.Point{0, 0}, End: image.Point{399, 150}, Thickness: 2},
+		{Start: image.Point{0, 0}, End: image.Point{399, 150}, Thickness: 2, AntiAlias: true},
+	}
+	// Both ends of every line are outside of the sub image
+	rect := image.Rect(150, 80, 250, 120)
+
+	for _, line := range lines {
+		full := getBlackDrawing()
+		drawing.DrawLine(&full, line, grad)
+
+		img := getBlackDrawing().Img
+		sub := drawing.Drawing{Img: img.(*image.RGBA).SubImage(rect).(draw.Image)}
+		drawing.DrawLine(&sub, line, grad)
+
+		bounds := full.Img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				expected := full.Img.At(x, y)
+				if !(image.Point{x, y}).In(rect) {
+					expected = getBlack()
+				}
+				if got := img.At(x, y); got != expected {
+					t.Fatalf("[%d;%d] of %v drawn on a sub image; \nExpected: %v; \nGot: %v", x, y, line, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestDrawLineClipped(t *testing.T) {
+	grad := color.GradientFromColor(color.ColorFromStdColor(getBlack()))
+	grad.SetMark(color.GradientMark{Col: color.ColorFromStdColor(getWhite()), Pos: 1})
+
+	lines := []drawing.Line{
+		{Start: image.Point{0, 100}, End: image.Point{399, 100}, Thickness: 3},
+		{Start: image.Point{200, 0}, End: image.Point{200, 199}, Thickness: 2},
+		{Start: image.Point{0, 0}, End: image.Point{399, 150}, Thickness: 2},
+		{Start: image.Point{0, 0}, End: image.Point{399, 150}, Thickness: 2, AntiAlias: true},
+	}
+	// Both ends of every line are outside of the clip
+	clip := image.Rect(150, 80, 250, 120)
+
+	for _, line := range lines {
+		full := getBlackDrawing()
+		drawing.DrawLine(&full, line, grad)
+
+		clipped := getBlackDrawing()
+		drawing.DrawLine(clipped.ClipTo(clip), line, grad)
+
+		bounds := full.Img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				expected := full.Img.At(x, y)
+				if !(image.Point{x, y}).In(clip) {
+					expected = getBlack()
+				}
+				if got := clipped.Img.At(x, y); got != expected {
+					t.Fatalf("[%d;%d] of %v drawn in a clip; \nExpected: %v; \nGot: %v", x, y, line, expected, got)
+				}
+			}
+		}
+	}
+}
+
 // Creates a 400 x 200 black drawing
 func getBlackDrawing() drawing.Drawing {
 	drawing := drawing.Drawing{
@@ -203,3 +427,11 @@ func getWhite() std_color.Color {
 func getBlack() std_color.Color {
 	return std_color.RGBA{0, 0, 0, 255}
 }
+
+// Channels may differ by one because of rounding
+func isCloseRGBA(a, b std_color.RGBA) bool {
+	isClose := func(x, y uint8) bool {
+		return max(x, y)-min(x, y) <= 1
+	}
+	return isClose(a.R, b.R) && isClose(a.G, b.G) && isClose(a.B, b.B) && isClose(a.A, b.A)
+}
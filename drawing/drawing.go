@@ -4,20 +4,44 @@ import (
 	"image"
 	"image/draw"
 	"math"
-)
 
-//TODO: create shape interface to use instead of line
+	"github.com/marattttt/generator/color"
+)
 
 type Drawing struct {
 	Img draw.Image
+	// Diagonal lines are anti-aliased, even if the line itself is not
+	AntiAlias bool
+	// Used for every pixel drawn, the default is source-over
+	Blend color.BlendMode
+	// Colors are blended and sRGB gradients are interpolated in linear light,
+	// the image still stores sRGB values
+	LinearLight bool
+}
+
+// Gives a copy of the drawing with another blend mode, the image is shared with the original
+// color.BlendDefault keeps the mode of the drawing
+func (d *Drawing) WithBlend(mode color.BlendMode) *Drawing {
+	if mode == color.BlendDefault {
+		return d
+	}
+
+	withBlend := *d
+	withBlend.Blend = mode
+	return &withBlend
 }
 
 // When used on a Drawing, a line does not have to be fully in bounds of the Drawing to take effect
 // and an a line outside of the bounds does not affect a drawing
+// Implements Shape
 type Line struct {
 	Start     image.Point
 	End       image.Point
 	Thickness int
+	// Diagonal lines get partially covered pixels on the edges instead of stair-steps
+	AntiAlias bool
+	// Dashes start at Start, the gradient is not affected by them
+	Dash DashPattern
 }
 
 type skewedLine struct {
@@ -25,6 +49,9 @@ type skewedLine struct {
 	secondaryStart, secondaryEnd int
 	thickness                    int
 	isSkewedX                    bool
+	// Secondary coordinate at primaryStart and its change per primary step, negative for descending lines
+	secondaryOrigin int
+	slope           float64
 }
 
 func (l Line) toSkewed() skewedLine {
@@ -36,21 +63,31 @@ func (l Line) toSkewed() skewedLine {
 		thickness: l.Thickness,
 	}
 
-	if skewed.isSkewedX {
-		skewed.primaryStart = min(l.Start.X, l.End.X)
-		skewed.primaryEnd = max(l.Start.X, l.End.X)
-		skewed.secondaryStart = min(l.Start.Y, l.End.Y)
-		skewed.secondaryEnd = max(l.Start.Y, l.End.Y)
-	} else {
-		skewed.primaryStart = min(l.Start.Y, l.End.Y)
-		skewed.primaryEnd = max(l.Start.Y, l.End.Y)
-		skewed.secondaryStart = min(l.Start.X, l.End.X)
-		skewed.secondaryEnd = max(l.Start.X, l.End.X)
+	from, to := l.Start, l.End
+	if !skewed.isSkewedX {
+		from, to = image.Point{from.Y, from.X}, image.Point{to.Y, to.X}
+	}
+	if from.X > to.X {
+		from, to = to, from
+	}
+
+	skewed.primaryStart = from.X
+	skewed.primaryEnd = to.X
+	skewed.secondaryStart = min(from.Y, to.Y)
+	skewed.secondaryEnd = max(from.Y, to.Y)
+	skewed.secondaryOrigin = from.Y
+	if to.X != from.X {
+		skewed.slope = float64(to.Y-from.Y) / float64(to.X-from.X)
 	}
 
 	return skewed
 }
 
+// Secondary coordinate of the middle of the line at the primary one
+func (s skewedLine) getSecondaryMiddle(primary int) int {
+	return s.secondaryOrigin + int(math.Round(s.slope*float64(primary-s.primaryStart)))
+}
+
 // Returns the rectanlgle of bounds in which the line is drawn
 // In general, returns more area than needed
 func (l1 Line) IsIntersectingWith(l2 Line) bool {
@@ -91,30 +128,155 @@ func (l Line) GetAffectedArea() image.Rectangle {
 	var rect image.Rectangle
 	startOffset, endOffset := getThicknessOffsets(skewed.thickness)
 
+	// Ends are inclusive when drawing, so max values are moved by one
+	// The secondary axis has an extra pixel on both sides for anti-aliased edges
 	if skewed.isSkewedX {
 		rect.Min.X = skewed.primaryStart
-		rect.Min.Y = skewed.secondaryStart + startOffset
-		rect.Max.X = skewed.primaryEnd
-		rect.Max.Y = skewed.secondaryEnd + endOffset
+		rect.Min.Y = skewed.secondaryStart + startOffset - 1
+		rect.Max.X = skewed.primaryEnd + 1
+		rect.Max.Y = skewed.secondaryEnd + endOffset + 2
 	} else {
 		rect.Min.Y = skewed.primaryStart
-		rect.Min.X = skewed.secondaryStart + startOffset
-		rect.Max.Y = skewed.primaryEnd
-		rect.Max.X = skewed.secondaryEnd + endOffset
+		rect.Min.X = skewed.secondaryStart + startOffset - 1
+		rect.Max.Y = skewed.primaryEnd + 1
+		rect.Max.X = skewed.secondaryEnd + endOffset + 2
 	}
 
 	return rect
 }
-func isInBounds(d *Drawing, line Line) bool {
-	bounds := d.Img.Bounds()
-	containsStart := bounds.Min.X <= line.Start.X &&
-		bounds.Max.X >= line.Start.X &&
-		bounds.Min.Y < line.Start.Y &&
-		bounds.Max.Y >= line.Start.Y
-	containsEnd := bounds.Min.X <= line.End.X &&
-		bounds.Max.X >= line.End.X &&
-		bounds.Min.Y < line.End.Y &&
-		bounds.Max.Y >= line.End.Y
-
-	return containsStart || containsEnd
+
+// Gives the pixels drawn by DrawLine
+func (l Line) Rasterize(plot PlotFunc) {
+	l.RasterizeIn(l.GetAffectedArea(), plot)
+}
+
+// Only the pixels inside of the bounds are walked, progress is still measured along the whole line
+func (l Line) RasterizeIn(bounds image.Rectangle, plot PlotFunc) {
+	if l.Thickness <= 0 || l.GetAffectedArea().Intersect(bounds).Empty() {
+		return
+	}
+
+	if l.Dash.isDashed() {
+		plot = l.filterDashes(plot)
+	}
+
+	isHorizontal := l.Start.Y == l.End.Y
+	isVertical := l.Start.X == l.End.X
+	startOffset, endOffset := getThicknessOffsets(l.Thickness)
+
+	if isHorizontal && !isVertical {
+		xStart := min(l.Start.X, l.End.X)
+		xEnd := max(l.Start.X, l.End.X)
+		for y := max(l.Start.Y+startOffset, bounds.Min.Y); y <= min(l.Start.Y+endOffset, bounds.Max.Y-1); y++ {
+			for x := max(xStart, bounds.Min.X); x <= min(xEnd, bounds.Max.X-1); x++ {
+				plot(x, y, 1, getProgress(xStart, xEnd, x))
+			}
+		}
+		return
+	}
+
+	if !isHorizontal && isVertical {
+		yStart := min(l.Start.Y, l.End.Y)
+		yEnd := max(l.Start.Y, l.End.Y)
+		for y := max(yStart, bounds.Min.Y); y <= min(yEnd, bounds.Max.Y-1); y++ {
+			for x := max(l.Start.X+startOffset, bounds.Min.X); x <= min(l.Start.X+endOffset, bounds.Max.X-1); x++ {
+				plot(x, y, 1, getProgress(yStart, yEnd, y))
+			}
+		}
+		return
+	}
+
+	if l.AntiAlias {
+		l.rasterizeAntiAliased(bounds, plot)
+		return
+	}
+
+	// Ends are inclusive, same as for straight lines
+	skewed := l.toSkewed()
+	skewedBounds := toSkewedBounds(bounds, skewed.isSkewedX)
+	for primary := max(skewed.primaryStart, skewedBounds.Min.X); primary <= min(skewed.primaryEnd, skewedBounds.Max.X-1); primary++ {
+		progress := getProgress(skewed.primaryStart, skewed.primaryEnd, primary)
+		secondaryMiddle := skewed.getSecondaryMiddle(primary)
+		secondaryStart := max(secondaryMiddle+startOffset, skewedBounds.Min.Y)
+		secondaryEnd := min(secondaryMiddle+endOffset, skewedBounds.Max.Y-1)
+		for secondary := secondaryStart; secondary <= secondaryEnd; secondary++ {
+			if skewed.isSkewedX {
+				plot(primary, secondary, 1, progress)
+			} else {
+				plot(secondary, primary, 1, progress)
+			}
+		}
+	}
+}
+
+// Swaps the axes of the bounds the same way as toSkewed does, so X is the primary axis
+func toSkewedBounds(bounds image.Rectangle, isSkewedX bool) image.Rectangle {
+	if isSkewedX {
+		return bounds
+	}
+	return image.Rect(bounds.Min.Y, bounds.Min.X, bounds.Max.Y, bounds.Max.X)
+}
+
+// Skips pixels in gaps between dashes, pixels are checked by their distance along the line
+func (l Line) filterDashes(plot PlotFunc) PlotFunc {
+	dir := PointFFromPoint(l.End.Sub(l.Start))
+	length := dir.Length()
+	if length == 0 {
+		return plot
+	}
+	dir = dir.Mul(1 / length)
+
+	return func(x, y int, coverage, progress float32) {
+		offset := PointFFromPoint(image.Point{x, y}.Sub(l.Start))
+		if l.Dash.isOn(offset.Dot(dir)) {
+			plot(x, y, coverage, progress)
+		}
+	}
+}
+
+// Coverage based version of the Xiaolin Wu's algorithm, which also supports thickness
+// Same as for other lines, thickness is applied to the secondary axis
+func (l Line) rasterizeAntiAliased(bounds image.Rectangle, plot PlotFunc) {
+	primaryStart, secondaryStart := l.Start.X, l.Start.Y
+	primaryEnd, secondaryEnd := l.End.X, l.End.Y
+	isSkewedX := math.Abs(float64(l.End.X-l.Start.X)) >= math.Abs(float64(l.End.Y-l.Start.Y))
+	if !isSkewedX {
+		primaryStart, secondaryStart = l.Start.Y, l.Start.X
+		primaryEnd, secondaryEnd = l.End.Y, l.End.X
+	}
+
+	if primaryStart > primaryEnd {
+		primaryStart, primaryEnd = primaryEnd, primaryStart
+		secondaryStart, secondaryEnd = secondaryEnd, secondaryStart
+	}
+
+	slope := 0.0
+	if primaryEnd != primaryStart {
+		slope = float64(secondaryEnd-secondaryStart) / float64(primaryEnd-primaryStart)
+	}
+	halfThickness := float64(l.Thickness) / 2
+
+	skewedBounds := toSkewedBounds(bounds, isSkewedX)
+	for primary := max(primaryStart, skewedBounds.Min.X); primary <= min(primaryEnd, skewedBounds.Max.X-1); primary++ {
+		middle := float64(secondaryStart) + slope*float64(primary-primaryStart)
+		from := middle - halfThickness
+		to := middle + halfThickness
+		progress := getProgress(primaryStart, primaryEnd, primary)
+
+		// Pixel n covers the range from n - 0.5 to n + 0.5
+		secondaryFrom := max(int(math.Floor(from+0.5)), skewedBounds.Min.Y)
+		secondaryTo := min(int(math.Ceil(to-0.5)), skewedBounds.Max.Y-1)
+		for secondary := secondaryFrom; secondary <= secondaryTo; secondary++ {
+			coverage := math.Min(to, float64(secondary)+0.5) - math.Max(from, float64(secondary)-0.5)
+			if coverage <= 0 {
+				continue
+			}
+
+			if isSkewedX {
+				plot(primary, secondary, float32(coverage), progress)
+			} else {
+				plot(secondary, primary, float32(coverage), progress)
+			}
+		}
+	}
 }
@@ -0,0 +1,182 @@
+package drawing
+
+import (
+	"image"
+)
+
+// Thickness is applied the same way as to a line, so the stroke is centered on the radius
+// A filled ellipse covers everything up to the outer edge of the stroke
+// Implements Shape
+type Ellipse struct {
+	Center           image.Point
+	RadiusX, RadiusY int
+	Thickness        int
+	Filled           bool
+}
+
+// Same as an ellipse with equal radii
+// Implements Shape
+type Circle struct {
+	Center    image.Point
+	Radius    int
+	Thickness int
+	Filled    bool
+}
+
+func (c Circle) toEllipse() Ellipse {
+	return Ellipse{
+		Center:    c.Center,
+		RadiusX:   c.Radius,
+		RadiusY:   c.Radius,
+		Thickness: c.Thickness,
+		Filled:    c.Filled,
+	}
+}
+
+func (c Circle) GetAffectedArea() image.Rectangle {
+	return c.toEllipse().GetAffectedArea()
+}
+
+func (c Circle) Rasterize(plot PlotFunc) {
+	c.toEllipse().Rasterize(plot)
+}
+
+func (c Circle) RasterizeIn(bounds image.Rectangle, plot PlotFunc) {
+	c.toEllipse().RasterizeIn(bounds, plot)
+}
+
+func (e Ellipse) isEmpty() bool {
+	return e.RadiusX < 0 || e.RadiusY < 0 || (!e.Filled && e.Thickness <= 0)
+}
+
+// Radii of the outermost drawn pixels
+func (e Ellipse) outerRadii() (rx, ry int) {
+	if e.Thickness <= 0 {
+		return e.RadiusX, e.RadiusY
+	}
+
+	_, endOffset := getThicknessOffsets(e.Thickness)
+	return e.RadiusX + endOffset, e.RadiusY + endOffset
+}
+
+// Radii of the ellipse left undrawn inside of the stroke
+// Negative values mean there is no hole
+func (e Ellipse) holeRadii() (rx, ry int) {
+	if e.Filled {
+		return -1, -1
+	}
+
+	startOffset, _ := getThicknessOffsets(e.Thickness)
+	return e.RadiusX + startOffset - 1, e.RadiusY + startOffset - 1
+}
+
+func (e Ellipse) GetAffectedArea() image.Rectangle {
+	if e.isEmpty() {
+		return image.Rectangle{}
+	}
+
+	rx, ry := e.outerRadii()
+	return image.Rect(e.Center.X-rx, e.Center.Y-ry, e.Center.X+rx+1, e.Center.Y+ry+1)
+}
+
+// The gradient goes from left to right
+func (e Ellipse) Rasterize(plot PlotFunc) {
+	e.RasterizeIn(e.GetAffectedArea(), plot)
+}
+
+// Rows and their spans are cut to the bounds, the gradient still spans the whole ellipse
+func (e Ellipse) RasterizeIn(bounds image.Rectangle, plot PlotFunc) {
+	if e.GetAffectedArea().Intersect(bounds).Empty() {
+		return
+	}
+
+	outerX, outerY := e.outerRadii()
+	holeX, holeY := e.holeRadii()
+	outer := getEllipseSpans(outerX, outerY)
+	var hole []int
+	if holeX >= 0 && holeY >= 0 {
+		hole = getEllipseSpans(holeX, holeY)
+	}
+
+	xStart := e.Center.X - outerX
+	xEnd := e.Center.X + outerX
+
+	for dy := max(-outerY, bounds.Min.Y-e.Center.Y); dy <= min(outerY, bounds.Max.Y-1-e.Center.Y); dy++ {
+		row := max(dy, -dy)
+		width := outer[row]
+
+		// Pixels with an offset up to holeWidth are inside of the hole
+		holeWidth := -1
+		if row < len(hole) {
+			holeWidth = hole[row]
+		}
+		// The run reaches the span of the next row outwards and is never empty, so the stroke has no gaps
+		if row < outerY {
+			holeWidth = min(holeWidth, outer[row+1])
+		}
+		holeWidth = min(holeWidth, width-1)
+
+		for dx := max(-width, bounds.Min.X-e.Center.X); dx <= min(width, bounds.Max.X-1-e.Center.X); dx++ {
+			if max(dx, -dx) <= holeWidth {
+				continue
+			}
+
+			x := e.Center.X + dx
+			plot(x, e.Center.Y+dy, 1, getProgress(xStart, xEnd, x))
+		}
+	}
+}
+
+// Uses the midpoint algorithm to find the half-width of every row of an ellipse
+// The index of a row is the distance from the center, values are the distances to the edge
+func getEllipseSpans(rx, ry int) []int {
+	spans := make([]int, ry+1)
+	if ry == 0 {
+		spans[0] = rx
+		return spans
+	}
+	if rx == 0 {
+		return spans
+	}
+
+	rx2 := float64(rx * rx)
+	ry2 := float64(ry * ry)
+
+	x, y := 0, ry
+	stepX := 0.0
+	stepY := 2 * rx2 * float64(y)
+
+	// Region where the slope is less than 1
+	p := ry2 - rx2*float64(ry) + rx2/4
+	for stepX < stepY {
+		spans[y] = max(spans[y], x)
+		x++
+		stepX += 2 * ry2
+		if p < 0 {
+			p += ry2 + stepX
+		} else {
+			y--
+			stepY -= 2 * rx2
+			p += ry2 + stepX - stepY
+		}
+	}
+
+	// Region where the slope is more than 1
+	fx := float64(x) + 0.5
+	fy := float64(y - 1)
+	p = ry2*fx*fx + rx2*fy*fy - rx2*ry2
+	for y >= 0 {
+		spans[y] = max(spans[y], x)
+		y--
+		stepY -= 2 * rx2
+		if p > 0 {
+			p += rx2 - stepY
+		} else {
+			x++
+			stepX += 2 * ry2
+			p += rx2 - stepY + stepX
+		}
+	}
+
+	return spans
+}
@@ -0,0 +1,161 @@
+package drawing_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+	"github.com/marattttt/generator/drawing"
+)
+
+func TestDrawFilledCircle(t *testing.T) {
+	white := getWhite()
+	black := getBlack()
+	srcDrawing := getBlackDrawing()
+	bounds := srcDrawing.Img.Bounds()
+	circle := drawing.Circle{
+		Center: image.Point{200, 100},
+		Radius: 50,
+		Filled: true,
+	}
+
+	col := color.ColorFromStdColor(white)
+	drawing.DrawShape(&srcDrawing, circle, color.GradientFromColor(col))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dx := x - 200
+			dy := y - 100
+			distSq := dx*dx + dy*dy
+			col := srcDrawing.Img.At(x, y)
+
+			// Pixels close to the edge may go either way
+			if distSq <= 49*49 && col != white {
+				t.Fatalf("[%d;%d] should be inside of the circle", x, y)
+			}
+			if distSq >= 51*51 && col != black {
+				t.Fatalf("[%d;%d] should be outside of the circle", x, y)
+			}
+		}
+	}
+}
+
+func TestDrawEllipseStroke(t *testing.T) {
+	white := getWhite()
+	black := getBlack()
+	srcDrawing := getBlackDrawing()
+	ellipse := drawing.Ellipse{
+		Center:    image.Point{200, 100},
+		RadiusX:   80,
+		RadiusY:   40,
+		Thickness: 3,
+	}
+
+	col := color.ColorFromStdColor(white)
+	drawing.DrawShape(&srcDrawing, ellipse, color.GradientFromColor(col))
+
+	if srcDrawing.Img.At(200, 100) != black {
+		t.Fatalf("Center of a not filled ellipse should not change")
+	}
+
+	for _, p := range []image.Point{{120, 100}, {280, 100}, {200, 60}, {200, 140}} {
+		if srcDrawing.Img.At(p.X, p.Y) != white {
+			t.Fatalf("[%d;%d] should be on the stroke", p.X, p.Y)
+		}
+	}
+	for _, p := range []image.Point{{116, 100}, {284, 100}, {200, 56}, {200, 144}} {
+		if srcDrawing.Img.At(p.X, p.Y) != black {
+			t.Fatalf("[%d;%d] should be outside of the stroke", p.X, p.Y)
+		}
+	}
+}
+
+func TestEllipseAffectedArea(t *testing.T) {
+	ellipse := drawing.Ellipse{
+		Center:    image.Point{50, 50},
+		RadiusX:   30,
+		RadiusY:   10,
+		Thickness: 4,
+	}
+
+	area := ellipse.GetAffectedArea()
+	var drawn image.Rectangle
+	ellipse.Rasterize(func(x, y int, coverage, progress float32) {
+		drawn = drawn.Union(image.Rect(x, y, x+1, y+1))
+	})
+
+	if area != drawn {
+		t.Fatalf("Affected area is not tight; \nExpected: %v; \nGot: %v", drawn, area)
+	}
+}
+
+func TestEllipseStrokeIsConnected(t *testing.T) {
+	ellipses := []drawing.Ellipse{
+		{Center: image.Point{20, 20}, RadiusX: 15, RadiusY: 4, Thickness: 1},
+		{Center: image.Point{20, 20}, RadiusX: 3, RadiusY: 17, Thickness: 1},
+		{Center: image.Point{50, 50}, RadiusX: 40, RadiusY: 6, Thickness: 2},
+	}
+
+	for _, ellipse := range ellipses {
+		pixels := map[image.Point]bool{}
+		var start image.Point
+		ellipse.Rasterize(func(x, y int, coverage, progress float32) {
+			start = image.Point{x, y}
+			pixels[start] = true
+		})
+
+		// Every pixel is reachable from any other one through its 8 neighbours
+		reached := map[image.Point]bool{start: true}
+		queue := []image.Point{start}
+		for len(queue) > 0 {
+			p := queue[0]
+			queue = queue[1:]
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					next := p.Add(image.Point{dx, dy})
+					if pixels[next] && !reached[next] {
+						reached[next] = true
+						queue = append(queue, next)
+					}
+				}
+			}
+		}
+
+		if len(reached) != len(pixels) {
+			t.Fatalf("Stroke of %v has gaps; \nExpected: %v connected pixels; \nGot: %v", ellipse, len(pixels), len(reached))
+		}
+	}
+}
+
+func TestDrawCircleRadialGradient(t *testing.T) {
+	srcDrawing := getBlackDrawing()
+	grad := color.GradientFromColor(color.ColorFromStdColor(getBlack()))
+	grad.SetMark(color.GradientMark{
+		Col: color.ColorFromStdColor(getWhite()),
+		Pos: 1,
+	})
+
+	circle := drawing.Circle{
+		Center: image.Point{200, 100},
+		Radius: 80,
+		Filled: true,
+	}
+	drawing.DrawShape(&srcDrawing, circle, color.NewRadialGradient(grad, circle.Center, 80))
+
+	// Brightness should grow from the center in every direction
+	for _, dir := range []image.Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		prev := uint32(0)
+		for dist := 0; dist < 80; dist += 10 {
+			p := circle.Center.Add(dir.Mul(dist))
+			r, _, _, _ := srcDrawing.Img.At(p.X, p.Y).RGBA()
+			if r < prev {
+				t.Fatalf("[%d;%d] should be brighter than the pixels closer to the center", p.X, p.Y)
+			}
+			prev = r
+		}
+	}
+
+	if srcDrawing.Img.At(200, 100) != getBlack() {
+		t.Fatalf("Center of a radial gradient should have the color of the first mark")
+	}
+}
@@ -0,0 +1,131 @@
+package drawing
+
+import (
+	"image"
+	"math"
+)
+
+// A point with sub-pixel precision
+// Pixel [x;y] is centered at x, y, same as with image.Point coordinates of a line
+type PointF struct {
+	X, Y float64
+}
+
+func PointFFromPoint(p image.Point) PointF {
+	return PointF{float64(p.X), float64(p.Y)}
+}
+
+// Rounds the coordinates to the nearest pixel
+func (p PointF) ToPoint() image.Point {
+	return image.Point{int(math.Round(p.X)), int(math.Round(p.Y))}
+}
+
+func (p PointF) Add(other PointF) PointF {
+	return PointF{p.X + other.X, p.Y + other.Y}
+}
+
+func (p PointF) Sub(other PointF) PointF {
+	return PointF{p.X - other.X, p.Y - other.Y}
+}
+
+func (p PointF) Mul(k float64) PointF {
+	return PointF{p.X * k, p.Y * k}
+}
+
+func (p PointF) Dot(other PointF) float64 {
+	return p.X*other.X + p.Y*other.Y
+}
+
+func (p PointF) Length() float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+// Distance from p to the infinite line going through start and end
+func (p PointF) distanceToLine(start, end PointF) float64 {
+	dir := end.Sub(start)
+	dirLength := dir.Length()
+	if dirLength == 0 {
+		return p.Sub(start).Length()
+	}
+
+	return math.Abs(dir.X*(p.Y-start.Y)-dir.Y*(p.X-start.X)) / dirLength
+}
+
+// Distance from p to the closest point between start and end
+func (p PointF) distanceToSegment(start, end PointF) float64 {
+	dir := end.Sub(start)
+	dirLengthSq := dir.Dot(dir)
+	if dirLengthSq == 0 {
+		return p.Sub(start).Length()
+	}
+
+	t := p.Sub(start).Dot(dir) / dirLengthSq
+	if t < 0 {
+		return p.Sub(start).Length()
+	}
+	if t > 1 {
+		return p.Sub(end).Length()
+	}
+	return p.distanceToLine(start, end)
+}
+
+// A line with sub-pixel precision, pixels on the edges get partial coverage
+// Thickness is measured across the line and ends are cut off straight at the end points
+// Implements Shape
+type LineF struct {
+	Start, End PointF
+	Thickness  float64
+}
+
+// Always rounds outwards, so the rectangle includes every partially covered pixel
+func (l LineF) GetAffectedArea() image.Rectangle {
+	if l.Thickness <= 0 {
+		return image.Rectangle{}
+	}
+
+	// Pixels are covered up to half a pixel away from the edge
+	return getPixelsAround(l.Thickness/2+0.5, l.Start, l.End)
+}
+
+// The gradient goes from the start to the end of the line
+func (l LineF) Rasterize(plot PlotFunc) {
+	l.RasterizeIn(l.GetAffectedArea(), plot)
+}
+
+func (l LineF) RasterizeIn(bounds image.Rectangle, plot PlotFunc) {
+	pixels := l.GetAffectedArea().Intersect(bounds)
+	if pixels.Empty() {
+		return
+	}
+
+	dir := l.End.Sub(l.Start)
+	length := dir.Length()
+	if length == 0 {
+		return
+	}
+	dir = dir.Mul(1 / length)
+	halfThickness := l.Thickness / 2
+
+	for y := pixels.Min.Y; y < pixels.Max.Y; y++ {
+		for x := pixels.Min.X; x < pixels.Max.X; x++ {
+			offset := PointF{float64(x), float64(y)}.Sub(l.Start)
+			along := offset.Dot(dir)
+			across := dir.X*offset.Y - dir.Y*offset.X
+
+			// Coverage is approximated by overlapping the pixel with the line on both axes
+			coverage := getOverlap(across-0.5, across+0.5, -halfThickness, halfThickness) *
+				getOverlap(along-0.5, along+0.5, 0, length)
+			if coverage <= 0 {
+				continue
+			}
+
+			progress := float32(math.Max(0, math.Min(1, along/length)))
+			plot(x, y, float32(math.Min(1, coverage)), progress)
+		}
+	}
+}
+
+// Length of the intersection of two ranges, zero if they do not intersect
+func getOverlap(start1, end1, start2, end2 float64) float64 {
+	return math.Max(0, math.Min(end1, end2)-math.Max(start1, start2))
+}
@@ -0,0 +1,49 @@
+package drawing_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/drawing"
+)
+
+func TestLineFSubPixelCoverage(t *testing.T) {
+	line := drawing.LineF{
+		Start:     drawing.PointF{X: 10, Y: 10.5},
+		End:       drawing.PointF{X: 50, Y: 10.5},
+		Thickness: 1,
+	}
+
+	area := line.GetAffectedArea()
+	line.Rasterize(func(x, y int, coverage, progress float32) {
+		if !(image.Point{x, y}).In(area) {
+			t.Fatalf("[%d;%d] is drawn outside of the affected area %v", x, y, area)
+		}
+		if y != 10 && y != 11 {
+			t.Fatalf("[%d;%d] should not be covered by a line between rows 10 and 11", x, y)
+		}
+		if x > 10 && x < 50 && math.Abs(float64(coverage)-0.5) > 0.001 {
+			t.Fatalf("[%d;%d] should be half covered, got %v", x, y, coverage)
+		}
+	})
+}
+
+func TestLineFTotalCoverage(t *testing.T) {
+	lines := []drawing.LineF{
+		{Start: drawing.PointF{X: 3.2, Y: 7.7}, End: drawing.PointF{X: 80.4, Y: 31.1}, Thickness: 2.5},
+		{Start: drawing.PointF{X: 40.5, Y: 2.25}, End: drawing.PointF{X: 12.1, Y: 90.9}, Thickness: 0.75},
+	}
+
+	for _, line := range lines {
+		var total float64
+		line.Rasterize(func(x, y int, coverage, progress float32) {
+			total += float64(coverage)
+		})
+
+		expected := line.End.Sub(line.Start).Length() * line.Thickness
+		if math.Abs(total-expected)/expected > 0.02 {
+			t.Fatalf("Covered area of a line should be close to its real area; \nExpected: %v; \nGot: %v", expected, total)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package drawing
+
+import (
+	"github.com/marattttt/generator/color"
+)
+
+// Replaces every pixel of the drawing with the closest color of the palette
+// Does nothing if the palette is empty
+func MapToPalette(d *Drawing, palette color.Palette) {
+	if len(palette) == 0 {
+		return
+	}
+
+	// Generated images usually have few distinct colors, so the search is done once per color
+	nearest := make(map[color.Color]color.Color)
+
+	bounds := d.Img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			col := color.ColorFromStdColor(d.Img.At(x, y))
+
+			mapped, ok := nearest[col]
+			if !ok {
+				mapped = palette.Nearest(col)
+				nearest[col] = mapped
+			}
+
+			d.Img.Set(x, y, mapped)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package drawing_test
+
+import (
+	"image"
+	std_color "image/color"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+	"github.com/marattttt/generator/drawing"
+)
+
+func TestMapToPalette(t *testing.T) {
+	grad := color.GradientFromColor(color.ColorFromStdColor(std_color.Black))
+	grad.SetMark(color.GradientMark{
+		Col: color.ColorFromStdColor(getWhite()),
+		Pos: 1,
+	})
+
+	d := getBlackDrawing()
+	drawing.DrawLine(&d, drawing.Line{Start: image.Point{0, 100}, End: image.Point{399, 100}, Thickness: 5}, grad)
+
+	palette := color.ExtractKMeans(d.Img, 4, color.DefaultKMeansIterations)
+	if len(palette) != 4 {
+		t.Fatalf("Expected 4 colors from a gradient, got %v", palette)
+	}
+
+	drawing.MapToPalette(&d, palette)
+
+	// Palette colors are stored with the precision of the image
+	stored := make(map[std_color.Color]bool)
+	for _, col := range palette {
+		stored[d.Img.ColorModel().Convert(col)] = true
+	}
+
+	bounds := d.Img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if col := d.Img.At(x, y); !stored[col] {
+				t.Fatalf("[%d;%d] has color %v, which is not in the palette %v", x, y, col, palette)
+			}
+		}
+	}
+
+	// Black background stays black, as it is in the palette
+	if got := d.Img.At(0, 0); got != getBlack() {
+		t.Fatalf("Background should stay black, got %v", got)
+	}
+}
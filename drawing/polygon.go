@@ -0,0 +1,125 @@
+package drawing
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// Decides which parts of a self-intersecting polygon are filled
+type FillRule int
+
+const (
+	// A point is inside if a ray from it crosses the outline an odd number of times
+	EvenOdd FillRule = iota
+	// A point is inside if the outline winds around it at least once
+	NonZero
+)
+
+// A filled polygon, the last point is connected to the first one
+// Points are corners of pixels, so a polygon from [0;0] to [10;10] fills 10 x 10 pixels
+// Implements Shape
+type Polygon struct {
+	Points []image.Point
+	Rule   FillRule
+}
+
+// A point where an edge crosses a scanline
+type edgeCrossing struct {
+	x         float64
+	direction int
+}
+
+func (p Polygon) GetAffectedArea() image.Rectangle {
+	if len(p.Points) < 3 {
+		return image.Rectangle{}
+	}
+
+	rect := image.Rectangle{Min: p.Points[0], Max: p.Points[0]}
+	for _, point := range p.Points[1:] {
+		rect.Min.X = min(rect.Min.X, point.X)
+		rect.Min.Y = min(rect.Min.Y, point.Y)
+		rect.Max.X = max(rect.Max.X, point.X)
+		rect.Max.Y = max(rect.Max.Y, point.Y)
+	}
+
+	return rect
+}
+
+// The gradient goes from left to right
+func (p Polygon) Rasterize(plot PlotFunc) {
+	p.RasterizeIn(p.GetAffectedArea(), plot)
+}
+
+// Only scanlines and spans inside of bounds are processed
+func (p Polygon) RasterizeIn(bounds image.Rectangle, plot PlotFunc) {
+	area := p.GetAffectedArea()
+	clipped := area.Intersect(bounds)
+	if clipped.Empty() {
+		return
+	}
+
+	crossings := make([]edgeCrossing, 0, len(p.Points))
+
+	for y := clipped.Min.Y; y < clipped.Max.Y; y++ {
+		crossings = p.getCrossings(float64(y)+0.5, crossings[:0])
+
+		winding := 0
+		for i := 0; i < len(crossings)-1; i++ {
+			if p.Rule == EvenOdd {
+				winding ^= 1
+			} else {
+				winding += crossings[i].direction
+			}
+
+			if winding == 0 {
+				continue
+			}
+
+			// Pixels are filled when their centers are inside of the span
+			xStart := int(math.Ceil(crossings[i].x - 0.5))
+			xEnd := int(math.Ceil(crossings[i+1].x-0.5)) - 1
+			xStart = max(xStart, clipped.Min.X)
+			xEnd = min(xEnd, clipped.Max.X-1)
+
+			for x := xStart; x <= xEnd; x++ {
+				plot(x, y, 1, getProgress(area.Min.X, area.Max.X-1, x))
+			}
+		}
+	}
+}
+
+// Appends crossings of the polygon edges with the horizontal line to dst, sorted by x
+func (p Polygon) getCrossings(y float64, dst []edgeCrossing) []edgeCrossing {
+	for i := range p.Points {
+		start := p.Points[i]
+		end := p.Points[(i+1)%len(p.Points)]
+		if start.Y == end.Y {
+			continue
+		}
+
+		y1 := float64(start.Y)
+		y2 := float64(end.Y)
+		if y < math.Min(y1, y2) || y >= math.Max(y1, y2) {
+			continue
+		}
+
+		direction := 1
+		if y2 < y1 {
+			direction = -1
+		}
+
+		x1 := float64(start.X)
+		x2 := float64(end.X)
+		dst = append(dst, edgeCrossing{
+			x:         x1 + (y-y1)*(x2-x1)/(y2-y1),
+			direction: direction,
+		})
+	}
+
+	sort.Slice(dst, func(i, j int) bool {
+		return dst[i].x < dst[j].x
+	})
+
+	return dst
+}
@@ -0,0 +1,90 @@
+package drawing_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+	"github.com/marattttt/generator/drawing"
+)
+
+func TestDrawPolygonSquare(t *testing.T) {
+	white := getWhite()
+	black := getBlack()
+	srcDrawing := getBlackDrawing()
+	bounds := srcDrawing.Img.Bounds()
+	polygon := drawing.Polygon{
+		Points: []image.Point{{10, 20}, {110, 20}, {110, 70}, {10, 70}},
+	}
+
+	col := color.ColorFromStdColor(white)
+	drawing.DrawShape(&srcDrawing, polygon, color.GradientFromColor(col))
+
+	area := polygon.GetAffectedArea()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			col := srcDrawing.Img.At(x, y)
+			if (image.Point{x, y}).In(area) {
+				if col != white {
+					t.Fatalf("[%d;%d] should be filled", x, y)
+				}
+			} else if col != black {
+				t.Fatalf("[%d;%d] should not change", x, y)
+			}
+		}
+	}
+}
+
+func TestDrawPolygonFillRules(t *testing.T) {
+	white := getWhite()
+	black := getBlack()
+
+	star := make([]image.Point, 5)
+	for i := range star {
+		angle := -math.Pi/2 + float64(i)*4*math.Pi/5
+		star[i] = image.Point{
+			X: 200 + int(80*math.Cos(angle)),
+			Y: 100 + int(80*math.Sin(angle)),
+		}
+	}
+
+	col := color.ColorFromStdColor(white)
+
+	evenOdd := getBlackDrawing()
+	drawing.DrawShape(&evenOdd, drawing.Polygon{Points: star, Rule: drawing.EvenOdd}, color.GradientFromColor(col))
+	if evenOdd.Img.At(200, 100) != black {
+		t.Fatalf("Center of a star should not be filled with the even-odd rule")
+	}
+
+	nonZero := getBlackDrawing()
+	drawing.DrawShape(&nonZero, drawing.Polygon{Points: star, Rule: drawing.NonZero}, color.GradientFromColor(col))
+	if nonZero.Img.At(200, 100) != white {
+		t.Fatalf("Center of a star should be filled with the non-zero rule")
+	}
+
+	// A point in one of the star's rays
+	if evenOdd.Img.At(200, 40) != white || nonZero.Img.At(200, 40) != white {
+		t.Fatalf("Rays of a star should be filled with any rule")
+	}
+}
+
+func TestDrawPolygonOutOfBounds(t *testing.T) {
+	white := getWhite()
+	srcDrawing := getBlackDrawing()
+	bounds := srcDrawing.Img.Bounds()
+	polygon := drawing.Polygon{
+		Points: []image.Point{{-1000, -1000}, {5000, -1000}, {5000, 5000}, {-1000, 5000}},
+	}
+
+	col := color.ColorFromStdColor(white)
+	drawing.DrawShape(&srcDrawing, polygon, color.GradientFromColor(col))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if srcDrawing.Img.At(x, y) != white {
+				t.Fatalf("A polygon larger than an image should cover all of it")
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package drawing
+
+import (
+	"image"
+)
+
+// Shape of the ends of a stroke
+type LineCap int
+
+const (
+	// The stroke ends exactly at the end point, same as a line
+	CapButt LineCap = iota
+	// The stroke goes past the end point by half of its thickness
+	CapSquare
+	// The stroke ends with a half circle around the end point
+	CapRound
+)
+
+// Shape of the outer corner where two parts of a stroke meet
+type LineJoin int
+
+const (
+	// Edges of the parts are extended until they meet, unless the tip is too long
+	JoinMiter LineJoin = iota
+	// The corner is cut off straight
+	JoinBevel
+	// The corner is rounded with a circle around the point
+	JoinRound
+)
+
+// Used when Polyline.MiterLimit is not set, same as in SVG
+const DefaultMiterLimit = 4
+
+// Lines connected one after another
+// MiterLimit is the longest allowed miter tip relative to half of the thickness,
+// longer miter joins are replaced with bevel joins
+// The gradient goes along the whole length of the path, also when it is dashed
+// Every dash gets its own caps
+// Implements Shape
+type Polyline struct {
+	Points     []image.Point
+	Thickness  int
+	Cap        LineCap
+	Join       LineJoin
+	MiterLimit float64
+	Dash       DashPattern
+}
+
+func (p Polyline) toStroke() strokePath {
+	points := make([]PointF, len(p.Points))
+	for i, point := range p.Points {
+		points[i] = PointFFromPoint(point)
+	}
+
+	return strokePath{
+		points:     points,
+		halfWidth:  float64(p.Thickness) / 2,
+		cap:        p.Cap,
+		join:       p.Join,
+		miterLimit: p.MiterLimit,
+		dash:       p.Dash,
+	}
+}
+
+func (p Polyline) GetAffectedArea() image.Rectangle {
+	return p.toStroke().getAffectedArea()
+}
+
+func (p Polyline) Rasterize(plot PlotFunc) {
+	p.RasterizeIn(p.GetAffectedArea(), plot)
+}
+
+func (p Polyline) RasterizeIn(bounds image.Rectangle, plot PlotFunc) {
+	p.toStroke().rasterizeIn(bounds, plot)
+}
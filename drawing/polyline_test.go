@@ -0,0 +1,128 @@
+package drawing_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/marattttt/generator/drawing"
+)
+
+func getCoveredPixels(t *testing.T, shape drawing.Shape) map[image.Point]float32 {
+	t.Helper()
+
+	area := shape.GetAffectedArea()
+	pixels := make(map[image.Point]float32)
+	shape.Rasterize(func(x, y int, coverage, progress float32) {
+		p := image.Point{x, y}
+		if !p.In(area) {
+			t.Fatalf("[%d;%d] is drawn outside of the affected area %v", x, y, area)
+		}
+		if _, ok := pixels[p]; ok {
+			t.Fatalf("[%d;%d] is drawn more than once", x, y)
+		}
+		pixels[p] = progress
+	})
+
+	return pixels
+}
+
+func TestPolylineJoins(t *testing.T) {
+	polyline := drawing.Polyline{
+		Points:    []image.Point{{50, 150}, {50, 50}, {150, 50}},
+		Thickness: 10,
+	}
+
+	cases := []struct {
+		join     drawing.LineJoin
+		covered  []image.Point
+		excluded []image.Point
+	}{
+		{drawing.JoinMiter, []image.Point{{45, 45}, {46, 46}}, nil},
+		{drawing.JoinBevel, []image.Point{{48, 48}}, []image.Point{{45, 45}, {46, 46}, {47, 47}}},
+		{drawing.JoinRound, []image.Point{{47, 47}}, []image.Point{{45, 45}, {46, 46}}},
+	}
+
+	for _, c := range cases {
+		polyline.Join = c.join
+		pixels := getCoveredPixels(t, polyline)
+
+		for _, p := range c.covered {
+			if _, ok := pixels[p]; !ok {
+				t.Fatalf("Join %v should cover [%d;%d]", c.join, p.X, p.Y)
+			}
+		}
+		for _, p := range c.excluded {
+			if _, ok := pixels[p]; ok {
+				t.Fatalf("Join %v should not cover [%d;%d]", c.join, p.X, p.Y)
+			}
+		}
+	}
+}
+
+func TestPolylineMiterLimit(t *testing.T) {
+	polyline := drawing.Polyline{
+		Points:     []image.Point{{50, 150}, {50, 50}, {150, 50}},
+		Thickness:  10,
+		Join:       drawing.JoinMiter,
+		MiterLimit: 1.2,
+	}
+
+	// A right angle needs a limit of at least sqrt(2)
+	if _, ok := getCoveredPixels(t, polyline)[image.Point{45, 45}]; ok {
+		t.Fatalf("Miter join over the limit should be replaced with a bevel join")
+	}
+}
+
+func TestPolylineCaps(t *testing.T) {
+	polyline := drawing.Polyline{
+		Points:    []image.Point{{50, 50}, {150, 50}},
+		Thickness: 10,
+	}
+
+	cases := []struct {
+		cap      drawing.LineCap
+		covered  []image.Point
+		excluded []image.Point
+	}{
+		{drawing.CapButt, []image.Point{{150, 50}}, []image.Point{{152, 50}}},
+		{drawing.CapSquare, []image.Point{{154, 50}, {154, 54}}, []image.Point{{156, 50}}},
+		{drawing.CapRound, []image.Point{{154, 50}}, []image.Point{{154, 54}, {156, 50}}},
+	}
+
+	for _, c := range cases {
+		polyline.Cap = c.cap
+		pixels := getCoveredPixels(t, polyline)
+
+		for _, p := range c.covered {
+			if _, ok := pixels[p]; !ok {
+				t.Fatalf("Cap %v should cover [%d;%d]", c.cap, p.X, p.Y)
+			}
+		}
+		for _, p := range c.excluded {
+			if _, ok := pixels[p]; ok {
+				t.Fatalf("Cap %v should not cover [%d;%d]", c.cap, p.X, p.Y)
+			}
+		}
+	}
+}
+
+func TestPolylineGradientGoesAlongPath(t *testing.T) {
+	polyline := drawing.Polyline{
+		Points:    []image.Point{{0, 0}, {100, 0}, {100, 100}},
+		Thickness: 1,
+	}
+
+	pixels := getCoveredPixels(t, polyline)
+	expected := map[image.Point]float32{
+		{0, 0}:     0,
+		{50, 0}:    0.25,
+		{100, 50}:  0.75,
+		{100, 100}: 1,
+	}
+
+	for p, progress := range expected {
+		if pixels[p] != progress {
+			t.Fatalf("[%d;%d] unexpected progress; \nExpected: %v; \nGot: %v", p.X, p.Y, progress, pixels[p])
+		}
+	}
+}
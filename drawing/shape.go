@@ -0,0 +1,106 @@
+package drawing
+
+import (
+	"image"
+
+	"github.com/marattttt/generator/color"
+)
+
+// A primitive that can be drawn on a Drawing
+// Same as with a line, a shape does not have to be fully in bounds of the Drawing to take effect
+type Shape interface {
+	// Returns the rectangle of bounds in which the shape is drawn
+	// May return more area than needed, but never less
+	GetAffectedArea() image.Rectangle
+	// Calls plot for every pixel covered by the shape, each pixel is passed at most once
+	// Pixels outside of a Drawing are not filtered out
+	Rasterize(plot PlotFunc)
+}
+
+// A shape that can skip pixels outside of the given bounds by itself
+// DrawShape uses it to avoid rasterizing the parts outside of a Drawing
+type ClippedShape interface {
+	Shape
+	RasterizeIn(bounds image.Rectangle, plot PlotFunc)
+}
+
+// Coverage is the part of the pixel covered by the shape and varies from 0 to 1
+// Progress is the position of the pixel used to sample a gradient and varies from 0 to 1
+type PlotFunc func(x, y int, coverage, progress float32)
+
+// Draws any shape, pixels outside of the Drawing are skipped
+// The paint is sampled for every pixel, so gradients may use both the progress and the coordinates
+func DrawShape(d *Drawing, shape Shape, grad color.Paint) {
+	// Lines are the only shapes with stair-steps Drawing.AntiAlias applies to
+	if line, ok := shape.(Line); ok && d.AntiAlias {
+		line.AntiAlias = true
+		shape = line
+	}
+
+	bounds := d.Img.Bounds()
+	if shape.GetAffectedArea().Intersect(bounds).Empty() {
+		return
+	}
+
+	grad = d.getPaint(grad)
+	plainColor := grad.ToPlainColor()
+
+	plot := func(x, y int, coverage, progress float32) {
+		if !(image.Point{x, y}).In(bounds) {
+			return
+		}
+
+		var col color.Color
+		if plainColor != nil {
+			col = *plainColor
+		} else {
+			col = grad.ColorAt(x, y, progress)
+		}
+
+		d.blend(x, y, col, coverage)
+	}
+
+	if clipped, ok := shape.(ClippedShape); ok {
+		clipped.RasterizeIn(bounds, plot)
+	} else {
+		shape.Rasterize(plot)
+	}
+}
+
+// Blends the color with the one already at [x;y] using the blend mode of the drawing
+// Partially covered pixels keep a part of the color already there
+func (d *Drawing) blend(x, y int, col color.Color, coverage float32) {
+	if coverage <= 0 || !(image.Point{x, y}).In(d.Img.Bounds()) {
+		return
+	}
+
+	existing := color.ColorFromStdColor(d.Img.At(x, y))
+
+	var newCol color.Color
+	if d.LinearLight {
+		newCol = d.Blend.BlendLinearCoverage(col, existing, coverage)
+	} else {
+		newCol = d.Blend.BlendCoverage(col, existing, coverage)
+	}
+	d.Img.Set(x, y, newCol)
+}
+
+// Gives the paint to sample colors from, gradients are switched to linear light if the drawing uses it
+func (d *Drawing) getPaint(paint color.Paint) color.Paint {
+	if d.LinearLight {
+		return color.InLinearLight(paint)
+	}
+	return paint
+}
+
+// Gives the position of pos between start and end, from 0 to 1
+func getProgress(start, end, pos int) float32 {
+	if end == start || pos <= start {
+		return 0
+	}
+	if pos >= end {
+		return 1
+	}
+
+	return float32(pos-start) / float32(end-start)
+}
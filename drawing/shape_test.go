@@ -0,0 +1,135 @@
+package drawing_test
+
+import (
+	"image"
+	std_color "image/color"
+	"math"
+	"testing"
+
+	"github.com/marattttt/generator/color"
+	"github.com/marattttt/generator/drawing"
+)
+
+func TestDrawShapeMatchesDrawLine(t *testing.T) {
+	grad := color.GradientFromColor(color.ColorFromStdColor(std_color.Black))
+	grad.SetMark(color.GradientMark{
+		Col: color.ColorFromStdColor(getWhite()),
+		Pos: 1,
+	})
+
+	lines := []drawing.Line{
+		{Start: image.Point{10, 100}, End: image.Point{300, 100}, Thickness: 1},
+		{Start: image.Point{200, 10}, End: image.Point{200, 150}, Thickness: 4},
+		{Start: image.Point{10, 10}, End: image.Point{150, 150}, Thickness: 3},
+	}
+
+	for _, line := range lines {
+		byLine := getBlackDrawing()
+		byShape := getBlackDrawing()
+
+		drawing.DrawLine(&byLine, line, grad)
+		drawing.DrawShape(&byShape, line, grad)
+
+		assertSameDrawings(t, byLine, byShape)
+	}
+}
+
+func TestLineAffectedAreaCoversLine(t *testing.T) {
+	lines := []drawing.Line{
+		{Start: image.Point{10, 20}, End: image.Point{60, 20}, Thickness: 1},
+		{Start: image.Point{10, 20}, End: image.Point{110, 30}, Thickness: 3},
+		{Start: image.Point{10, 30}, End: image.Point{110, 20}, Thickness: 2},
+		{Start: image.Point{50, 10}, End: image.Point{40, 90}, Thickness: 4},
+		{Start: image.Point{50, 10}, End: image.Point{40, 90}, Thickness: 4, AntiAlias: true},
+	}
+
+	for _, line := range lines {
+		area := line.GetAffectedArea()
+		// Rasterize only walks the affected area, so the line is rasterized in wider bounds
+		line.RasterizeIn(image.Rect(-1000, -1000, 1000, 1000), func(x, y int, coverage, progress float32) {
+			if !(image.Point{x, y}).In(area) {
+				t.Fatalf("[%d;%d] of %v is drawn outside of the affected area %v", x, y, line, area)
+			}
+		})
+	}
+}
+
+func TestDiagonalLineFollowsSlope(t *testing.T) {
+	// Shallow line going up, every column has one pixel on the line, both ends included
+	line := drawing.Line{Start: image.Point{10, 30}, End: image.Point{110, 20}, Thickness: 1}
+
+	columns := 0
+	line.Rasterize(func(x, y int, coverage, progress float32) {
+		expected := 30 - int(math.Round(float64(x-10)/10))
+		if y != expected {
+			t.Fatalf("[%d;%d] is off the line, expected y %d", x, y, expected)
+		}
+		columns++
+	})
+
+	if columns != 101 {
+		t.Fatalf("Unexpected number of pixels; \nExpected: %v; \nGot: %v", 101, columns)
+	}
+}
+
+func TestRasterizeInMatchesRasterize(t *testing.T) {
+	shapes := []drawing.ClippedShape{
+		drawing.Line{Start: image.Point{-50, 40}, End: image.Point{300, 40}, Thickness: 5},
+		drawing.Line{Start: image.Point{60, 300}, End: image.Point{60, -20}, Thickness: 2},
+		drawing.Line{Start: image.Point{-30, -10}, End: image.Point{250, 120}, Thickness: 3},
+		drawing.Line{Start: image.Point{250, -10}, End: image.Point{-30, 120}, Thickness: 3, AntiAlias: true},
+		drawing.Line{Start: image.Point{20, 150}, End: image.Point{70, -40}, Thickness: 2},
+		drawing.Line{Start: image.Point{0, 0}, End: image.Point{200, 90}, Thickness: 2, Dash: drawing.DashPattern{Lengths: []float64{7, 3}}},
+		drawing.Ellipse{Center: image.Point{80, 60}, RadiusX: 90, RadiusY: 40, Thickness: 3},
+		drawing.Circle{Center: image.Point{30, 90}, Radius: 50, Filled: true},
+	}
+	bounds := []image.Rectangle{
+		image.Rect(0, 0, 64, 64),
+		image.Rect(64, 32, 100, 50),
+		image.Rect(-100, -100, 0, 200),
+	}
+
+	type plotted struct{ coverage, progress float32 }
+	for _, shape := range shapes {
+		for _, rect := range bounds {
+			expected := make(map[image.Point]plotted)
+			shape.Rasterize(func(x, y int, coverage, progress float32) {
+				if (image.Point{x, y}).In(rect) {
+					expected[image.Point{x, y}] = plotted{coverage, progress}
+				}
+			})
+
+			got := make(map[image.Point]plotted)
+			shape.RasterizeIn(rect, func(x, y int, coverage, progress float32) {
+				if !(image.Point{x, y}).In(rect) {
+					t.Fatalf("[%d;%d] of %v is outside of %v", x, y, shape, rect)
+				}
+				got[image.Point{x, y}] = plotted{coverage, progress}
+			})
+
+			if len(got) != len(expected) {
+				t.Fatalf("%v in %v gives %d pixels instead of %d", shape, rect, len(got), len(expected))
+			}
+			for p, val := range expected {
+				if got[p] != val {
+					t.Fatalf("%v of %v in %v differs; \nExpected: %v; \nGot: %v", p, shape, rect, val, got[p])
+				}
+			}
+		}
+	}
+}
+
+func assertSameDrawings(t *testing.T, expected, got drawing.Drawing) {
+	t.Helper()
+
+	bounds := expected.Img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			col1 := expected.Img.At(x, y)
+			col2 := got.Img.At(x, y)
+			if col1 != col2 {
+				t.Fatalf("[%d;%d] colors do not match; \nExpected: %v; \nGot: %v", x, y, col1, col2)
+			}
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package drawing
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// Remembers every pixel of a stroke, so that overlapping parts of it are plotted once
+// Only covered pixels are kept, so thin strokes over a large area stay cheap
+type strokeMask struct {
+	area   image.Rectangle
+	pixels map[image.Point]float32
+}
+
+func newStrokeMask(area image.Rectangle) *strokeMask {
+	return &strokeMask{
+		area:   area,
+		pixels: make(map[image.Point]float32),
+	}
+}
+
+// Pixels outside of the mask and already covered pixels are ignored
+func (m *strokeMask) set(x, y int, progress float32) {
+	p := image.Point{x, y}
+	if !p.In(m.area) {
+		return
+	}
+
+	if _, ok := m.pixels[p]; ok {
+		return
+	}
+	m.pixels[p] = progress
+}
+
+// Pixels are plotted row by row, from left to right
+func (m *strokeMask) plot(plot PlotFunc) {
+	points := make([]image.Point, 0, len(m.pixels))
+	for p := range m.pixels {
+		points = append(points, p)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Y != points[j].Y {
+			return points[i].Y < points[j].Y
+		}
+		return points[i].X < points[j].X
+	})
+
+	for _, p := range points {
+		plot(p.X, p.Y, 1, m.pixels[p])
+	}
+}
+
+// Pixels of a rectangle around the points, padded and clipped to the mask
+func (m *strokeMask) getPixelsAround(padding float64, points ...PointF) image.Rectangle {
+	return getPixelsAround(padding, points...).Intersect(m.area)
+}
+
+// Pixels of a rectangle around the points, padded by the given distance
+func getPixelsAround(padding float64, points ...PointF) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		minX = math.Min(minX, p.X)
+		minY = math.Min(minY, p.Y)
+		maxX = math.Max(maxX, p.X)
+		maxY = math.Max(maxY, p.Y)
+	}
+
+	return image.Rect(
+		int(math.Floor(minX-padding)),
+		int(math.Floor(minY-padding)),
+		int(math.Ceil(maxX+padding))+1,
+		int(math.Ceil(maxY+padding))+1,
+	)
+}
+
+// A thick path through the points
+// The gradient goes along the path
+// A part of a longer path can keep the gradient of the whole path,
+// lengthOffset is the distance from the start of the whole path and totalLength is its length
+type strokePath struct {
+	points     []PointF
+	halfWidth  float64
+	cap        LineCap
+	join       LineJoin
+	miterLimit float64
+	dash       DashPattern
+
+	lengthOffset float64
+	totalLength  float64
+}
+
+func (s strokePath) getAffectedArea() image.Rectangle {
+	if len(s.points) == 0 || s.halfWidth <= 0 {
+		return image.Rectangle{}
+	}
+
+	// Corners of square caps and tips of miter joins stick out further than half width
+	padding := s.halfWidth
+	if s.cap == CapSquare {
+		padding = s.halfWidth * math.Sqrt2
+	}
+	if s.join == JoinMiter {
+		padding = math.Max(padding, s.halfWidth*s.getMiterLimit())
+	}
+
+	return getPixelsAround(padding, s.points...)
+}
+
+func (s strokePath) getMiterLimit() float64 {
+	if s.miterLimit < 1 {
+		return DefaultMiterLimit
+	}
+	return s.miterLimit
+}
+
+// Length of the whole path the stroke is a part of
+func (s strokePath) getTotalLength() float64 {
+	if s.totalLength > 0 {
+		return s.totalLength
+	}
+
+	lengths := getPathLengths(s.points)
+	return lengths[len(lengths)-1]
+}
+
+// Running length of the path up to every point
+func getPathLengths(points []PointF) []float64 {
+	lengths := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		lengths[i] = lengths[i-1] + points[i].Sub(points[i-1]).Length()
+	}
+	return lengths
+}
+
+func (s strokePath) rasterizeIn(bounds image.Rectangle, plot PlotFunc) {
+	area := s.getAffectedArea().Intersect(bounds)
+	if area.Empty() {
+		return
+	}
+
+	mask := newStrokeMask(area)
+	for _, dash := range s.getDashes(s.dash) {
+		dash.rasterizeInto(mask)
+	}
+	mask.plot(plot)
+}
+
+func (s strokePath) rasterizeInto(mask *strokeMask) {
+	// Repeated points do not have a direction, so joins around them cannot be built
+	points := make([]PointF, 0, len(s.points))
+	for i, p := range s.points {
+		if i == 0 || p != s.points[i-1] {
+			points = append(points, p)
+		}
+	}
+
+	totalLength := s.getTotalLength()
+	getProgress := func(length float64) float32 {
+		if totalLength == 0 {
+			return 0
+		}
+		return float32(math.Max(0, math.Min(1, (s.lengthOffset+length)/totalLength)))
+	}
+
+	// Dashes of zero length are dots
+	if len(points) == 1 {
+		if s.cap == CapRound {
+			s.rasterizeDisk(mask, points[0], getProgress(0))
+		}
+		return
+	}
+
+	lengths := getPathLengths(points)
+
+	last := len(points) - 1
+	for i := 1; i < len(points); i++ {
+		segmentLength := lengths[i] - lengths[i-1]
+
+		// Square caps extend the first and the last segments
+		var extendStart, extendEnd float64
+		if s.cap == CapSquare && i == 1 {
+			extendStart = s.halfWidth / segmentLength
+		}
+		if s.cap == CapSquare && i == last {
+			extendEnd = s.halfWidth / segmentLength
+		}
+
+		s.rasterizeSegment(mask, points[i-1], points[i], extendStart, extendEnd, func(t float64) float32 {
+			return getProgress(lengths[i-1] + t*segmentLength)
+		})
+	}
+
+	// Joins and caps only fill the gaps left between segments
+	for i := 1; i < last; i++ {
+		s.rasterizeJoin(mask, points[i-1], points[i], points[i+1], getProgress(lengths[i]))
+	}
+
+	if s.cap == CapRound {
+		s.rasterizeDisk(mask, points[0], getProgress(0))
+		s.rasterizeDisk(mask, points[last], getProgress(lengths[last]))
+	}
+}
+
+// Covers the pixels within half width from the segment, ends of the segment are cut off
+// Ends can be extended by a part of the segment length
+// getProgress receives the position along the segment, from 0 to 1
+func (s strokePath) rasterizeSegment(mask *strokeMask, start, end PointF, extendStart, extendEnd float64, getProgress func(t float64) float32) {
+	dir := end.Sub(start)
+	dirLengthSq := dir.Dot(dir)
+	if dirLengthSq == 0 {
+		return
+	}
+
+	pixels := mask.getPixelsAround(s.halfWidth, start.Sub(dir.Mul(extendStart)), end.Add(dir.Mul(extendEnd)))
+	maxDistance := s.halfWidth * math.Sqrt(dirLengthSq)
+	for y := pixels.Min.Y; y < pixels.Max.Y; y++ {
+		dy := float64(y) - start.Y
+
+		// Only a span of every row can be close enough to the segment,
+		// it is widened by a pixel on both sides against rounding and every pixel in it is checked
+		alongFrom, alongTo, ok := getSpan(dir.X, -extendStart*dirLengthSq-dy*dir.Y, (1+extendEnd)*dirLengthSq-dy*dir.Y)
+		if !ok {
+			continue
+		}
+		acrossFrom, acrossTo, ok := getSpan(dir.Y, dy*dir.X-maxDistance, dy*dir.X+maxDistance)
+		if !ok {
+			continue
+		}
+
+		xFrom := max(pixels.Min.X, int(math.Floor(start.X+math.Max(alongFrom, acrossFrom)))-1)
+		xTo := min(pixels.Max.X-1, int(math.Ceil(start.X+math.Min(alongTo, acrossTo)))+1)
+		for x := xFrom; x <= xTo; x++ {
+			center := PointF{float64(x), float64(y)}
+			t := center.Sub(start).Dot(dir) / dirLengthSq
+			if t < -extendStart || t > 1+extendEnd {
+				continue
+			}
+
+			if center.distanceToLine(start, end) <= s.halfWidth {
+				mask.set(x, y, getProgress(t))
+			}
+		}
+	}
+}
+
+// Gives the range of x for which k * x is between from and to
+// Any x fits if k is 0 and from and to are on different sides of 0
+func getSpan(k, from, to float64) (spanFrom, spanTo float64, ok bool) {
+	if k == 0 {
+		return math.Inf(-1), math.Inf(1), from <= 0 && to >= 0
+	}
+	if k < 0 {
+		from, to = to, from
+	}
+
+	return from / k, to / k, true
+}
+
+// Fills the outer corner between segments from prev to point and from point to next
+func (s strokePath) rasterizeJoin(mask *strokeMask, prev, point, next PointF, progress float32) {
+	if s.join == JoinRound {
+		s.rasterizeDisk(mask, point, progress)
+		return
+	}
+
+	dirIn := point.Sub(prev)
+	dirOut := next.Sub(point)
+	cross := dirIn.X*dirOut.Y - dirIn.Y*dirOut.X
+	// Segments go in the same direction, there is no corner
+	if cross == 0 && dirIn.Dot(dirOut) > 0 {
+		return
+	}
+
+	// Normals are turned to the outer side of the corner
+	side := -1.0
+	if cross < 0 {
+		side = 1
+	}
+	normalIn := PointF{-dirIn.Y, dirIn.X}.Mul(side / dirIn.Length())
+	normalOut := PointF{-dirOut.Y, dirOut.X}.Mul(side / dirOut.Length())
+
+	cornerIn := point.Add(normalIn.Mul(s.halfWidth))
+	cornerOut := point.Add(normalOut.Mul(s.halfWidth))
+
+	if s.join == JoinMiter {
+		bisector := normalIn.Add(normalOut)
+		bisectorLength := bisector.Length()
+		if bisectorLength > 0 {
+			bisector = bisector.Mul(1 / bisectorLength)
+			// Ratio of the distance to the tip to half width
+			ratio := 1 / bisector.Dot(normalIn)
+			if ratio <= s.getMiterLimit() {
+				tip := point.Add(bisector.Mul(s.halfWidth * ratio))
+				rasterizeConvex(mask, progress, point, cornerIn, tip, cornerOut)
+				return
+			}
+		}
+	}
+
+	rasterizeConvex(mask, progress, point, cornerIn, cornerOut)
+}
+
+func (s strokePath) rasterizeDisk(mask *strokeMask, center PointF, progress float32) {
+	pixels := mask.getPixelsAround(s.halfWidth, center)
+	for y := pixels.Min.Y; y < pixels.Max.Y; y++ {
+		for x := pixels.Min.X; x < pixels.Max.X; x++ {
+			if (PointF{float64(x), float64(y)}).Sub(center).Length() <= s.halfWidth {
+				mask.set(x, y, progress)
+			}
+		}
+	}
+}
+
+// Covers the pixels with centers inside of a convex polygon, points may go in any direction
+func rasterizeConvex(mask *strokeMask, progress float32, points ...PointF) {
+	pixels := mask.getPixelsAround(0, points...)
+	for y := pixels.Min.Y; y < pixels.Max.Y; y++ {
+		for x := pixels.Min.X; x < pixels.Max.X; x++ {
+			center := PointF{float64(x), float64(y)}
+			hasPositive, hasNegative := false, false
+
+			for i, start := range points {
+				end := points[(i+1)%len(points)]
+				cross := (end.X-start.X)*(center.Y-start.Y) - (end.Y-start.Y)*(center.X-start.X)
+				hasPositive = hasPositive || cross > 0
+				hasNegative = hasNegative || cross < 0
+			}
+
+			if !(hasPositive && hasNegative) {
+				mask.set(x, y, progress)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/marattttt/generator/command"
+)
+
+// What happens to the other commands when one of them fails
+type ErrorPolicy int
+
+const (
+	// No more commands are started after the first error, the running ones are still waited for
+	StopOnError ErrorPolicy = iota
+	// Every command runs, even the ones drawn over a failed command
+	ContinueOnError
+)
+
+var errorPolicyNames = map[ErrorPolicy]string{
+	StopOnError:     "stop",
+	ContinueOnError: "continue",
+}
+
+func (p ErrorPolicy) String() string {
+	if name, ok := errorPolicyNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Error returned by the command at the index of Generator.Commands
+type CommandError struct {
+	Index   int
+	Command command.Command
+	Err     error
+}
+
+func (commandError CommandError) Error() string {
+	return fmt.Sprintf("Command %d (%T) failed: %v", commandError.Index, commandError.Command, commandError.Err)
+}
+
+func (commandError CommandError) Unwrap() error {
+	return commandError.Err
+}
+
+// Errors of all the failed commands, sorted by their indexes
+// Every command is reported once, even if it failed on several tiles
+type CommandErrors []CommandError
+
+func (commandErrors CommandErrors) Error() string {
+	messages := make([]string, len(commandErrors))
+	for i, commandError := range commandErrors {
+		messages[i] = commandError.Error()
+	}
+	return fmt.Sprintf("%d of the commands failed: %s", len(commandErrors), strings.Join(messages, "; "))
+}
+
+// Lets errors.Is and errors.As look into the errors of the commands
+func (commandErrors CommandErrors) Unwrap() []error {
+	errs := make([]error, len(commandErrors))
+	for i, commandError := range commandErrors {
+		errs[i] = commandError
+	}
+	return errs
+}
+
+// Gathers the errors of the commands from several workers
+type errorCollector struct {
+	mu     sync.Mutex
+	errs   map[int]CommandError
+	policy ErrorPolicy
+}
+
+func newErrorCollector(policy ErrorPolicy) *errorCollector {
+	return &errorCollector{errs: make(map[int]CommandError), policy: policy}
+}
+
+// Keeps the first error of every command, nil errors are ignored
+func (c *errorCollector) add(index int, comm command.Command, err error) {
+	if err == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.errs[index]; !ok {
+		c.errs[index] = CommandError{Index: index, Command: comm, Err: err}
+	}
+}
+
+// Commands should not be started anymore by the policy
+func (c *errorCollector) isStopped() bool {
+	if c.policy == ContinueOnError {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.errs) > 0
+}
+
+// Nil if no command failed, so the result can be returned as an error directly
+func (c *errorCollector) getErrors() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.errs) == 0 {
+		return nil
+	}
+
+	errs := make(CommandErrors, 0, len(c.errs))
+	for _, commandError := range c.errs {
+		errs = append(errs, commandError)
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Index < errs[j].Index })
+	return errs
+}
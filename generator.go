@@ -1,7 +1,9 @@
 package generator
 
 import (
-	"sync"
+	"context"
+	"errors"
+	"runtime"
 
 	"github.com/marattttt/generator/command"
 	"github.com/marattttt/generator/drawing"
@@ -12,26 +14,109 @@ import (
 type Generator struct {
 	Target   *drawing.Drawing
 	Commands []command.Command
+	// Number of commands or tiles drawn at the same time, GOMAXPROCS if less than 1
+	Workers int
+	// Whether the other commands still run after one of them fails, the default is StopOnError
+	ErrorPolicy ErrorPolicy
 }
 
+// Same as ApplyCommandsContext with a context that is never cancelled
 func (g Generator) ApplyCommands() (cycles int, err error) {
-	toExecute, left := command.FilterRelatedCommands(g.Commands)
-
-	cycles = 0
-	for len(toExecute) > 0 {
-		var wg sync.WaitGroup
-		for _, comm := range toExecute {
-			wg.Add(1)
-			go func(comm command.Command) {
-				defer wg.Done()
-				comm.Execute(g.Target)
-			}(comm)
+	return g.ApplyCommandsContext(context.Background())
+}
+
+// Commands run as soon as all the earlier commands overlapping with them are done,
+// so the result is the same as running them one by one
+// Cycles is the depth of the dependency graph, the least number of steps the commands can be run in
+// When the context is done, no more commands are started, the running ones are waited for
+// and the error of the context is returned, the target is then left partially drawn
+// Errors of the commands are returned as CommandErrors, joined with the error of the context if both happen
+// Cycles is 0 if not every command was run
+func (g Generator) ApplyCommandsContext(ctx context.Context) (cycles int, err error) {
+	graph := command.NewDependencyGraph(g.Commands, g.Target.Img.Bounds())
+
+	waiting := make([]int, len(g.Commands))
+	ready := make([]int, 0)
+	for i, dependencies := range graph.Dependencies {
+		waiting[i] = len(dependencies)
+		if waiting[i] == 0 {
+			ready = append(ready, i)
 		}
+	}
+
+	type result struct {
+		index int
+		err   error
+	}
 
-		wg.Wait()
-		toExecute, left = command.FilterRelatedCommands(left)
-		cycles++
+	toRun := make(chan int)
+	done := make(chan result)
+	for worker := 0; worker < g.getWorkers(); worker++ {
+		go func() {
+			for i := range toRun {
+				done <- result{i, g.Commands[i].Execute(g.Target)}
+			}
+		}()
 	}
+	defer close(toRun)
 
-	return cycles, nil
+	errs := newErrorCollector(g.ErrorPolicy)
+	isStopped := func() bool {
+		return ctx.Err() != nil || errs.isStopped()
+	}
+
+	running := 0
+	started := 0
+	cancelled := ctx.Done()
+	for (len(ready) > 0 && !isStopped()) || running > 0 {
+		// A nil channel is never ready, so nothing is sent when no command is ready or the commands are stopped
+		var send chan<- int
+		next := -1
+		if len(ready) > 0 && !isStopped() {
+			send = toRun
+			next = ready[0]
+		}
+
+		select {
+		case send <- next:
+			ready = ready[1:]
+			running++
+			started++
+		case finished := <-done:
+			running--
+			errs.add(finished.index, g.Commands[finished.index], finished.err)
+			for _, dependent := range graph.Dependents[finished.index] {
+				waiting[dependent]--
+				if waiting[dependent] == 0 {
+					ready = append(ready, dependent)
+				}
+			}
+		case <-cancelled:
+			// Only wakes the loop up, the running commands are still waited for
+			cancelled = nil
+		}
+	}
+
+	if started == len(g.Commands) {
+		cycles = graph.GetDepth()
+	}
+	return cycles, joinErrors(ctx.Err(), errs.getErrors())
+}
+
+func (g Generator) getWorkers() int {
+	if g.Workers < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return g.Workers
+}
+
+// Nil if both errors are nil, otherwise the errors are kept unwrappable with errors.Is and errors.As
+func joinErrors(err1, err2 error) error {
+	if err1 == nil {
+		return err2
+	}
+	if err2 == nil {
+		return err1
+	}
+	return errors.Join(err1, err2)
 }
@@ -1,9 +1,13 @@
 package generator_test
 
 import (
+	"context"
+	"errors"
 	"image"
 	std_color "image/color"
 	"image/draw"
+	"math/rand"
+	"sync"
 	"testing"
 
 	"github.com/marattttt/generator"
@@ -98,6 +102,470 @@ func TestCyclesNumber(t *testing.T) {
 	}
 }
 
+func TestCommandBlendMode(t *testing.T) {
+	target := getBlackDrawing()
+	target.Blend = color.BlendAdditive
+	gen := generator.Generator{
+		Target: &target,
+	}
+
+	gray := color.Color{R: 0x8080, G: 0x8080, B: 0x8080, A: 0xffff}
+	line := drawing.Line{
+		Start:     image.Point{0, 10},
+		End:       image.Point{100, 10},
+		Thickness: 1,
+	}
+
+	gen.Commands = []command.Command{
+		command.DrawLineCommand{
+			Line: line,
+			Grad: color.GradientFromColor(gray),
+		},
+		command.DrawLineCommand{
+			Line:  line,
+			Grad:  color.GradientFromColor(gray),
+			Blend: color.BlendSrc,
+		},
+		command.DrawLineCommand{
+			Line: line,
+			Grad: color.GradientFromColor(gray),
+		},
+	}
+
+	gen.ApplyCommands()
+
+	// Additive, then replaced with source, then additive again
+	expected := std_color.RGBA{255, 255, 255, 255}
+	got := target.Img.At(50, 10)
+	if got != expected {
+		t.Fatalf("Unexpected color after commands with different blend modes; \nExpected: %v; \nGot: %v", expected, got)
+	}
+
+	// Commands should not change the blend mode of the target
+	if target.Blend != color.BlendAdditive {
+		t.Fatalf("Blend mode of the target changed to %v", target.Blend)
+	}
+}
+
+func TestApplyCommandsKeepsOrder(t *testing.T) {
+	red := color.GradientFromColor(color.MustParse("#f00"))
+	green := color.GradientFromColor(color.MustParse("#0f0"))
+	blue := color.GradientFromColor(color.MustParse("#00f"))
+
+	// Blue overlaps only green, which overlaps red, so blue has to wait for green
+	gen := generator.Generator{}
+	gen.Commands = []command.Command{
+		command.DrawLineCommand{Line: drawing.Line{Start: image.Point{0, 10}, End: image.Point{100, 10}, Thickness: 1}, Grad: red},
+		command.DrawLineCommand{Line: drawing.Line{Start: image.Point{50, 10}, End: image.Point{200, 10}, Thickness: 1}, Grad: green},
+		command.DrawLineCommand{Line: drawing.Line{Start: image.Point{150, 10}, End: image.Point{300, 10}, Thickness: 1}, Grad: blue},
+	}
+
+	target := getBlackDrawing()
+	gen.Target = &target
+	cycles, err := gen.ApplyCommands()
+	if err != nil {
+		t.Fatalf("Error when applying commands; \n%v", err)
+	}
+	if cycles != 3 {
+		t.Fatalf("Unexpected number of cycles; \nExpected: %v; \nGot: %v", 3, cycles)
+	}
+
+	expected := std_color.RGBA{0, 0, 255, 255}
+	if got := target.Img.At(175, 10); got != expected {
+		t.Fatalf("Later command is covered by an earlier one; \nExpected: %v; \nGot: %v", expected, got)
+	}
+}
+
+func TestApplyCommandsMatchesSequential(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	blends := []color.BlendMode{color.BlendDefault, color.BlendSrc, color.BlendMultiply, color.BlendDifference}
+
+	for run := 0; run < 10; run++ {
+		commands := make([]command.Command, 200)
+		for i := range commands {
+			col := color.ColorFromNRGBA64(std_color.NRGBA64{
+				R: uint16(random.Intn(0x10000)),
+				G: uint16(random.Intn(0x10000)),
+				B: uint16(random.Intn(0x10000)),
+				A: uint16(random.Intn(0x10000)),
+			})
+			line := drawing.Line{
+				Start:     image.Point{random.Intn(400), random.Intn(200)},
+				End:       image.Point{random.Intn(400), random.Intn(200)},
+				Thickness: random.Intn(5) + 1,
+				AntiAlias: random.Intn(2) == 0,
+			}
+
+			commands[i] = command.DrawLineCommand{
+				Line:  line,
+				Grad:  color.GradientFromColor(col),
+				Blend: blends[random.Intn(len(blends))],
+			}
+		}
+
+		sequential := getBlackDrawing()
+		for _, comm := range commands {
+			comm.Execute(&sequential)
+		}
+
+		target := getBlackDrawing()
+		gen := generator.Generator{Target: &target, Commands: commands}
+		if _, err := gen.ApplyCommands(); err != nil {
+			t.Fatalf("Error when applying commands; \n%v", err)
+		}
+
+		bounds := target.Img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if col1, col2 := sequential.Img.At(x, y), target.Img.At(x, y); col1 != col2 {
+					t.Fatalf("[%d;%d] differs from sequential execution; \nExpected: %v; \nGot: %v", x, y, col1, col2)
+				}
+			}
+		}
+	}
+}
+
+func TestApplyCommandsOffCanvas(t *testing.T) {
+	white := color.GradientFromColor(color.MustParse("#fff"))
+	commands := []command.Command{
+		// Crosses the drawing, but most of it is far outside
+		command.DrawLineCommand{Line: drawing.Line{Start: image.Point{-60000, -60000}, End: image.Point{60000, 60000}, Thickness: 3}, Grad: white},
+		// Fully outside, has nothing to wait for but still runs
+		command.DrawLineCommand{Line: drawing.Line{Start: image.Point{1000, 1000}, End: image.Point{2000, 1500}, Thickness: 3}, Grad: white},
+		command.DrawLineCommand{Line: drawing.Line{Start: image.Point{0, 100}, End: image.Point{399, 100}, Thickness: 5}, Grad: white},
+	}
+
+	sequential := getBlackDrawing()
+	for _, comm := range commands {
+		comm.Execute(&sequential)
+	}
+
+	tracker := &runTracker{}
+	tracked := make([]command.Command, len(commands))
+	for i, comm := range commands {
+		tracked[i] = trackedCommand{Command: comm, tracker: tracker}
+	}
+
+	target := getBlackDrawing()
+	gen := generator.Generator{Target: &target, Commands: tracked}
+	cycles, err := gen.ApplyCommands()
+	if err != nil {
+		t.Fatalf("Error when applying commands; \n%v", err)
+	}
+	if cycles != 2 {
+		t.Fatalf("Unexpected number of cycles; \nExpected: %v; \nGot: %v", 2, cycles)
+	}
+	if tracker.started != len(commands) {
+		t.Fatalf("Unexpected number of commands run; \nExpected: %v; \nGot: %v", len(commands), tracker.started)
+	}
+
+	bounds := target.Img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if col1, col2 := sequential.Img.At(x, y), target.Img.At(x, y); col1 != col2 {
+				t.Fatalf("[%d;%d] differs from sequential execution; \nExpected: %v; \nGot: %v", x, y, col1, col2)
+			}
+		}
+	}
+}
+
+func TestApplyCommandsTiledMatchesSequential(t *testing.T) {
+	random := rand.New(rand.NewSource(3))
+	commands := getRandomCommands(random, 300)
+	// Dithering in the middle, error diffusion has to run on the whole area
+	commands = append(commands[:150], append([]command.Command{
+		command.DitherCommand{Area: image.Rect(20, 20, 300, 150), Method: drawing.DitherBayer4, Quantizer: color.Levels(4)},
+		command.DitherCommand{Area: image.Rect(100, 0, 400, 100), Method: drawing.DitherFloydSteinberg, Quantizer: color.Levels(3)},
+	}, commands[150:]...)...)
+
+	sequential := getBlackDrawing()
+	for _, comm := range commands {
+		comm.Execute(&sequential)
+	}
+
+	for _, tileSize := range []int{0, 7, 17, 1000} {
+		target := getBlackDrawing()
+		gen := generator.Generator{Target: &target, Commands: commands}
+		if err := gen.ApplyCommandsTiled(tileSize); err != nil {
+			t.Fatalf("Error when applying commands; \n%v", err)
+		}
+
+		bounds := target.Img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if col1, col2 := sequential.Img.At(x, y), target.Img.At(x, y); col1 != col2 {
+					t.Fatalf("[%d;%d] with tile size %d differs from sequential execution; \nExpected: %v; \nGot: %v",
+						x, y, tileSize, col1, col2)
+				}
+			}
+		}
+	}
+}
+
+func TestApplyCommandsContextWorkers(t *testing.T) {
+	commands := getRandomCommands(rand.New(rand.NewSource(5)), 200)
+	sequential := getBlackDrawing()
+	for _, comm := range commands {
+		comm.Execute(&sequential)
+	}
+
+	for _, workers := range []int{1, 3} {
+		tracker := &runTracker{}
+		tracked := make([]command.Command, len(commands))
+		for i, comm := range commands {
+			tracked[i] = trackedCommand{Command: comm, tracker: tracker}
+		}
+
+		target := getBlackDrawing()
+		gen := generator.Generator{Target: &target, Commands: tracked, Workers: workers}
+		if _, err := gen.ApplyCommandsContext(context.Background()); err != nil {
+			t.Fatalf("Error when applying commands; \n%v", err)
+		}
+
+		if tracker.maxRunning > workers {
+			t.Fatalf("Too many commands run at the same time; \nExpected at most: %v; \nGot: %v", workers, tracker.maxRunning)
+		}
+		if tracker.started != len(commands) {
+			t.Fatalf("Unexpected number of commands run; \nExpected: %v; \nGot: %v", len(commands), tracker.started)
+		}
+
+		bounds := target.Img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if col1, col2 := sequential.Img.At(x, y), target.Img.At(x, y); col1 != col2 {
+					t.Fatalf("[%d;%d] with %d workers differs from sequential execution; \nExpected: %v; \nGot: %v",
+						x, y, workers, col1, col2)
+				}
+			}
+		}
+	}
+}
+
+func TestApplyCommandsContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Commands do not overlap, so all of them are ready at once
+	// The first one cancels the context, the others block until it is done
+	tracker := &runTracker{}
+	commands := make([]command.Command, 100)
+	for i := range commands {
+		line := drawing.Line{Start: image.Point{i, 0}, End: image.Point{i, 10}, Thickness: 1}
+		comm := command.DrawLineCommand{Line: line, Grad: color.GradientFromColor(color.MustParse("#fff"))}
+		isFirst := i == 0
+		commands[i] = trackedCommand{Command: comm, tracker: tracker, onExecute: func() {
+			if isFirst {
+				cancel()
+			}
+			<-ctx.Done()
+		}}
+	}
+
+	const workers = 2
+	target := getBlackDrawing()
+	gen := generator.Generator{Target: &target, Commands: commands, Workers: workers}
+	cycles, err := gen.ApplyCommandsContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Unexpected error; \nExpected: %v; \nGot: %v", context.Canceled, err)
+	}
+	if cycles != 0 {
+		t.Fatalf("Unexpected number of cycles; \nExpected: %v; \nGot: %v", 0, cycles)
+	}
+
+	// One more command may be handed to a worker while the cancellation is noticed
+	if tracker.started > workers+1 {
+		t.Fatalf("Commands kept starting after cancellation; \nExpected at most: %v; \nGot: %v", workers+1, tracker.started)
+	}
+	if tracker.running != 0 {
+		t.Fatalf("Returned before the running commands finished; \nStill running: %v", tracker.running)
+	}
+
+	// A context done in advance starts nothing
+	tracker = &runTracker{}
+	for i := range commands {
+		commands[i] = trackedCommand{Command: commands[i].(trackedCommand).Command, tracker: tracker}
+	}
+	if _, err := gen.ApplyCommandsContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Unexpected error; \nExpected: %v; \nGot: %v", context.Canceled, err)
+	}
+	if tracker.started != 0 {
+		t.Fatalf("Commands started with a cancelled context; \nGot: %v", tracker.started)
+	}
+}
+
+func TestApplyCommandsErrors(t *testing.T) {
+	commands := getRandomCommands(rand.New(rand.NewSource(7)), 100)
+	commands[10] = failingCommand{commands[10]}
+	commands[60] = failingCommand{commands[60]}
+
+	// Failed commands draw nothing
+	sequential := getBlackDrawing()
+	for _, comm := range commands {
+		comm.Execute(&sequential)
+	}
+
+	tracker := &runTracker{}
+	tracked := make([]command.Command, len(commands))
+	for i, comm := range commands {
+		tracked[i] = trackedCommand{Command: comm, tracker: tracker}
+	}
+
+	target := getBlackDrawing()
+	gen := generator.Generator{Target: &target, Commands: tracked, ErrorPolicy: generator.ContinueOnError}
+	cycles, err := gen.ApplyCommands()
+	checkCommandErrors(t, err, 10, 60)
+	if cycles == 0 {
+		t.Fatalf("Cycles are not given though every command was run")
+	}
+	if tracker.started != len(commands) {
+		t.Fatalf("Unexpected number of commands run; \nExpected: %v; \nGot: %v", len(commands), tracker.started)
+	}
+
+	bounds := target.Img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if col1, col2 := sequential.Img.At(x, y), target.Img.At(x, y); col1 != col2 {
+				t.Fatalf("[%d;%d] differs from sequential execution; \nExpected: %v; \nGot: %v", x, y, col1, col2)
+			}
+		}
+	}
+}
+
+func TestApplyCommandsStopsOnError(t *testing.T) {
+	// Every command overlaps the previous one, so they run one by one
+	tracker := &runTracker{}
+	commands := make([]command.Command, 20)
+	for i := range commands {
+		line := drawing.Line{Start: image.Point{0, 10}, End: image.Point{100, 10}, Thickness: 1}
+		var comm command.Command = command.DrawLineCommand{Line: line, Grad: color.GradientFromColor(color.MustParse("#fff"))}
+		if i == 5 {
+			comm = failingCommand{comm}
+		}
+		commands[i] = trackedCommand{Command: comm, tracker: tracker}
+	}
+
+	target := getBlackDrawing()
+	gen := generator.Generator{Target: &target, Commands: commands}
+	cycles, err := gen.ApplyCommands()
+	checkCommandErrors(t, err, 5)
+	if cycles != 0 {
+		t.Fatalf("Unexpected number of cycles; \nExpected: %v; \nGot: %v", 0, cycles)
+	}
+	if tracker.started != 6 {
+		t.Fatalf("Commands kept running after an error; \nExpected: %v; \nGot: %v", 6, tracker.started)
+	}
+}
+
+func TestApplyCommandsTiledErrors(t *testing.T) {
+	commands := getRandomCommands(rand.New(rand.NewSource(9)), 100)
+	// Spans many tiles, but is reported once
+	commands[20] = failingCommand{command.DrawLineCommand{
+		Line: drawing.Line{Start: image.Point{0, 50}, End: image.Point{400, 50}, Thickness: 3},
+		Grad: color.GradientFromColor(color.MustParse("#fff")),
+	}}
+	commands[40] = failingCommand{commands[40]}
+
+	sequential := getBlackDrawing()
+	for _, comm := range commands {
+		comm.Execute(&sequential)
+	}
+
+	target := getBlackDrawing()
+	gen := generator.Generator{Target: &target, Commands: commands, ErrorPolicy: generator.ContinueOnError}
+	checkCommandErrors(t, gen.ApplyCommandsTiled(16), 20, 40)
+
+	bounds := target.Img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if col1, col2 := sequential.Img.At(x, y), target.Img.At(x, y); col1 != col2 {
+				t.Fatalf("[%d;%d] differs from sequential execution; \nExpected: %v; \nGot: %v", x, y, col1, col2)
+			}
+		}
+	}
+
+	target = getBlackDrawing()
+	gen = generator.Generator{Target: &target, Commands: commands, ErrorPolicy: generator.StopOnError}
+	err := gen.ApplyCommandsTiled(16)
+	var commandErrors generator.CommandErrors
+	if !errors.As(err, &commandErrors) || commandErrors[0].Index != 20 {
+		t.Fatalf("Unexpected error; \nExpected the error of command 20 first; \nGot: %v", err)
+	}
+}
+
+func TestCommandErrorsMessage(t *testing.T) {
+	err := generator.CommandErrors{
+		{Index: 3, Command: failingCommand{}, Err: errBroken},
+		{Index: 7, Command: command.DitherCommand{}, Err: errBroken},
+	}
+
+	expected := "2 of the commands failed: Command 3 (generator_test.failingCommand) failed: broken input; " +
+		"Command 7 (command.DitherCommand) failed: broken input"
+	if err.Error() != expected {
+		t.Fatalf("Unexpected error message; \nExpected: %q; \nGot: %q", expected, err.Error())
+	}
+}
+
+// Lines, circles, polygons, polylines and curves with random paints and blend modes, partly outside of the drawing
+func getRandomCommands(random *rand.Rand, count int) []command.Command {
+	blends := []color.BlendMode{color.BlendDefault, color.BlendSrc, color.BlendMultiply, color.BlendDifference}
+	getPoint := func() image.Point {
+		return image.Point{random.Intn(500) - 50, random.Intn(300) - 50}
+	}
+	getColor := func() color.Color {
+		return color.ColorFromNRGBA64(std_color.NRGBA64{
+			R: uint16(random.Intn(0x10000)),
+			G: uint16(random.Intn(0x10000)),
+			B: uint16(random.Intn(0x10000)),
+			A: uint16(random.Intn(0x10000)),
+		})
+	}
+	getPaint := func() color.Paint {
+		grad := color.GradientFromColor(getColor())
+		grad.SetMark(color.GradientMark{Col: getColor(), Pos: 1})
+		if random.Intn(2) == 0 {
+			return grad
+		}
+		return color.NewLinearGradient(grad, getPoint(), getPoint())
+	}
+
+	commands := make([]command.Command, count)
+	for i := range commands {
+		blend := blends[random.Intn(len(blends))]
+		thickness := random.Intn(5) + 1
+
+		switch random.Intn(5) {
+		case 0:
+			line := drawing.Line{Start: getPoint(), End: getPoint(), Thickness: thickness, AntiAlias: random.Intn(2) == 0}
+			switch random.Intn(3) {
+			case 0:
+				line.End.Y = line.Start.Y
+			case 1:
+				line.End.X = line.Start.X
+			}
+			commands[i] = command.DrawLineCommand{Line: line, Grad: getPaint(), Blend: blend}
+		case 1:
+			circle := drawing.Circle{Center: getPoint(), Radius: random.Intn(60), Thickness: thickness, Filled: random.Intn(2) == 0}
+			commands[i] = command.DrawCircleCommand{Circle: circle, Grad: getPaint(), Blend: blend}
+		case 2:
+			polygon := drawing.Polygon{Points: []image.Point{getPoint(), getPoint(), getPoint(), getPoint()}}
+			commands[i] = command.DrawPolygonCommand{Polygon: polygon, Grad: getPaint(), Blend: blend}
+		case 3:
+			polyline := drawing.Polyline{
+				Points:    []image.Point{getPoint(), getPoint(), getPoint()},
+				Thickness: thickness,
+				Cap:       drawing.CapRound,
+				Join:      drawing.JoinMiter,
+			}
+			commands[i] = command.DrawPolylineCommand{Polyline: polyline, Grad: getPaint(), Blend: blend}
+		default:
+			curve := drawing.CubicBezier{Start: getPoint(), Control1: getPoint(), Control2: getPoint(), End: getPoint(), Thickness: thickness}
+			commands[i] = command.DrawShapeCommand{Shape: curve, Grad: getPaint(), Blend: blend}
+		}
+	}
+
+	return commands
+}
+
 // Creates a 400 x 200 black drawing
 func getBlackDrawing() drawing.Drawing {
 	drawing := drawing.Drawing{
@@ -122,3 +590,72 @@ func getWhite() std_color.Color {
 func getBlack() std_color.Color {
 	return std_color.RGBA{0, 0, 0, 255}
 }
+
+// Counts the commands running at the same time
+type runTracker struct {
+	mu         sync.Mutex
+	started    int
+	running    int
+	maxRunning int
+}
+
+type trackedCommand struct {
+	command.Command
+	tracker   *runTracker
+	onExecute func()
+}
+
+func (c trackedCommand) Execute(d *drawing.Drawing) error {
+	c.tracker.mu.Lock()
+	c.tracker.started++
+	c.tracker.running++
+	c.tracker.maxRunning = max(c.tracker.maxRunning, c.tracker.running)
+	c.tracker.mu.Unlock()
+
+	defer func() {
+		c.tracker.mu.Lock()
+		c.tracker.running--
+		c.tracker.mu.Unlock()
+	}()
+
+	if c.onExecute != nil {
+		c.onExecute()
+	}
+	return c.Command.Execute(d)
+}
+
+var errBroken = errors.New("broken input")
+
+// Checks that the error holds the errors of the commands at the indexes, in the same order
+func checkCommandErrors(t *testing.T, err error, indexes ...int) {
+	t.Helper()
+
+	var commandErrors generator.CommandErrors
+	if !errors.As(err, &commandErrors) {
+		t.Fatalf("Error does not hold the errors of the commands; \nGot: %v", err)
+	}
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("Error does not wrap the error of the command; \nGot: %v", err)
+	}
+
+	if len(commandErrors) != len(indexes) {
+		t.Fatalf("Unexpected number of failed commands; \nExpected: %v; \nGot: %v", len(indexes), len(commandErrors))
+	}
+	for i, commandError := range commandErrors {
+		if commandError.Index != indexes[i] {
+			t.Fatalf("Unexpected index of a failed command; \nExpected: %v; \nGot: %v", indexes[i], commandError.Index)
+		}
+		if commandError.Command == nil {
+			t.Fatalf("Failed command %v is not given", commandError.Index)
+		}
+	}
+}
+
+// Fails without drawing anything
+type failingCommand struct {
+	command.Command
+}
+
+func (c failingCommand) Execute(d *drawing.Drawing) error {
+	return errBroken
+}
@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"image"
+	"sync"
+
+	"github.com/marattttt/generator/command"
+)
+
+// Side of a tile in pixels used when no tile size is given
+const DefaultTileSize = 64
+
+// Splits the target into square tiles, every tile is drawn by one worker with the commands touching it,
+// clipped to the tile and in the order of the commands, so the result is the same as running them one by one
+// Unlike ApplyCommands, overlapping commands do not wait for each other as a whole,
+// so a single large command does not hold back the rest
+// Commands implementing command.WholeAreaCommand run alone on the whole target after the earlier ones
+// Tile size less than 1 is replaced with DefaultTileSize, the number of workers is given by Workers
+// Errors of the commands are returned as CommandErrors, a command failing on several tiles is reported once
+// With StopOnError, tiles being drawn stop before their next command, tiles not started yet are left untouched
+func (g Generator) ApplyCommandsTiled(tileSize int) error {
+	if tileSize < 1 {
+		tileSize = DefaultTileSize
+	}
+
+	errs := newErrorCollector(g.ErrorPolicy)
+	start := 0
+	for i, comm := range g.Commands {
+		if whole, ok := comm.(command.WholeAreaCommand); ok && whole.NeedsWholeArea() {
+			g.renderTiles(start, g.Commands[start:i], tileSize, errs)
+			if errs.isStopped() {
+				return errs.getErrors()
+			}
+			errs.add(i, comm, comm.Execute(g.Target))
+			start = i + 1
+		}
+	}
+	g.renderTiles(start, g.Commands[start:], tileSize, errs)
+
+	return errs.getErrors()
+}
+
+// First is the index of the first of the commands in Generator.Commands, used to report errors
+func (g Generator) renderTiles(first int, commands []command.Command, tileSize int, errs *errorCollector) {
+	if len(commands) == 0 || errs.isStopped() {
+		return
+	}
+
+	tiles := getTiles(g.Target.Img.Bounds(), commands, tileSize)
+
+	toRender := make(chan tile)
+	var wg sync.WaitGroup
+	for worker := 0; worker < g.getWorkers(); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range toRender {
+				clipped := g.Target.ClipTo(t.rect)
+				for _, i := range t.commands {
+					if errs.isStopped() {
+						break
+					}
+					errs.add(first+i, commands[i], commands[i].Execute(clipped))
+				}
+			}
+		}()
+	}
+
+	for _, t := range tiles {
+		if errs.isStopped() {
+			break
+		}
+		if len(t.commands) > 0 {
+			toRender <- t
+		}
+	}
+	close(toRender)
+	wg.Wait()
+}
+
+type tile struct {
+	rect image.Rectangle
+	// Indexes of the commands touching the tile, in ascending order
+	commands []int
+}
+
+// Tiles cover the bounds row by row, tiles on the right and bottom edges may be smaller
+func getTiles(bounds image.Rectangle, commands []command.Command, tileSize int) []tile {
+	columns := (bounds.Dx() + tileSize - 1) / tileSize
+	rows := (bounds.Dy() + tileSize - 1) / tileSize
+
+	tiles := make([]tile, columns*rows)
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			corner := bounds.Min.Add(image.Point{column * tileSize, row * tileSize})
+			rect := image.Rectangle{Min: corner, Max: corner.Add(image.Point{tileSize, tileSize})}
+			tiles[row*columns+column].rect = rect.Intersect(bounds)
+		}
+	}
+
+	for i, comm := range commands {
+		area := comm.GetAffectedArea().Intersect(bounds)
+		if area.Empty() {
+			continue
+		}
+
+		first := area.Min.Sub(bounds.Min).Div(tileSize)
+		last := area.Max.Sub(bounds.Min).Sub(image.Point{1, 1}).Div(tileSize)
+		for row := first.Y; row <= last.Y; row++ {
+			for column := first.X; column <= last.X; column++ {
+				tiles[row*columns+column].commands = append(tiles[row*columns+column].commands, i)
+			}
+		}
+	}
+
+	return tiles
+}